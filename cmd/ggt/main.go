@@ -3,10 +3,13 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"os/exec"
+	"runtime/debug"
 
 	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/diff"
 	"github.com/fadyat/ggt/internal/plugins"
 	"github.com/fadyat/ggt/internal/renderer"
 )
@@ -18,27 +21,154 @@ func exit(err error, msg string) {
 	}
 }
 
+// version is set at build time with `-ldflags "-X main.version=v1.0.0"`.
+var version = ""
+
+// buildVersion returns the version of the binary, falling back to the module
+// version, when the tool is installed with `go install`.
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return "devel"
+}
+
 func main() {
 	f, err := internal.ParseFlags()
 	exit(err, "parse flags")
 
-	parser := internal.NewParser(f)
-	file, err := parser.GenerateMissingTests()
-	if err != nil {
+	f.Version = buildVersion()
+	if f.ShowVersion {
+		fmt.Println(f.Version)
+		return
+	}
+
+	logger := internal.NewLogger(os.Stderr, f)
+
+	// stdout is reserved for the generated files in the preview modes,
+	// so the reports are printed to the stderr.
+	var report io.Writer = os.Stdout
+	if f.Preview() {
+		report = os.Stderr
+	}
+
+	if !f.IsPackageInput() {
+		err = generate(f, logger)
 		if errors.Is(err, internal.ErrNoMissingTests) {
-			fmt.Println("no missing tests")
+			_, _ = fmt.Fprintln(report, "no missing tests")
 			return
 		}
 
 		exit(err, "generate tests")
+		return
+	}
+
+	files, err := f.InputFiles()
+	exit(err, "list input files")
+
+	var summary internal.Summary
+	for _, file := range files {
+		ff, forFileErr := f.ForFile(file)
+		if forFileErr != nil {
+			summary.Add(file, forFileErr)
+			continue
+		}
+
+		summary.Add(file, generate(ff, logger))
+	}
+
+	_, _ = fmt.Fprintln(report, summary.String())
+	if len(summary.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+func generate(f *internal.Flags, logger *slog.Logger) error {
+	parser := internal.NewParser(f, logger)
+	r := renderer.NewRenderer(f, logger)
+
+	file, err := parser.GenerateMissingTests()
+	if errors.Is(err, internal.ErrNoMissingTests) {
+		return updateMarker(f, r, err)
+	}
+
+	if err != nil {
+		return err
 	}
 
-	r := renderer.NewRenderer(f)
-	err = r.Render(plugins.NewPluggableFile(file))
-	exit(err, "render tests")
+	outputs, err := r.Render(plugins.NewPluggableFile(file, f, logger))
+	if err != nil {
+		return fmt.Errorf("render tests: %w", err)
+	}
 
-	out, err := exec.Command("gofmt", "-w", f.OutputFile).CombinedOutput()
+	return output(f, outputs)
+}
+
+// updateMarker updates the marker of the output file, when there are no
+// missing tests, the error of the generation is returned after it.
+func updateMarker(f *internal.Flags, r *renderer.Renderer, generateErr error) error {
+	out, err := r.RenderMarker()
 	if err != nil {
-		exit(fmt.Errorf("%s: %s", err, out), "format generated file")
+		return fmt.Errorf("render marker: %w", err)
+	}
+
+	if out == nil {
+		return generateErr
+	}
+
+	if err = output(f, []*renderer.Output{out}); err != nil {
+		return err
+	}
+
+	return generateErr
+}
+
+// output writes the outputs or prints them in the preview modes.
+func output(f *internal.Flags, outputs []*renderer.Output) error {
+	switch {
+	case f.DryRun:
+		return printOutputs(os.Stdout, outputs)
+	case f.Diff:
+		return printDiff(os.Stdout, outputs)
+	default:
+		return renderer.Write(outputs)
 	}
 }
+
+// printOutputs prints the content of the generated files, each file is
+// preceded by its path, when there are multiple files.
+func printOutputs(w io.Writer, outputs []*renderer.Output) error {
+	for _, out := range outputs {
+		if len(outputs) > 1 {
+			if _, err := fmt.Fprintf(w, "// %s\n", out.Path); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(out.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printDiff(w io.Writer, outputs []*renderer.Output) error {
+	for _, out := range outputs {
+		var oldName = "a/" + out.Path
+		if out.Existing == nil {
+			oldName = "/dev/null"
+		}
+
+		if _, err := w.Write(diff.Unified(oldName, "b/"+out.Path, out.Existing, out.Content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
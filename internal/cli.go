@@ -3,33 +3,568 @@ package internal
 import (
 	"flag"
 	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/fadyat/ggt/internal/lo"
 )
 
 type Flags struct {
 	InputFile  string
 	OutputFile string
+
+	// TypeCheck enables the type-aware parsing of the package.
+	TypeCheck bool
+
+	// Only and Exclude are the regular expressions, which are used to
+	// select the functions by the full name, like `Struct.Method`.
+	Only    string
+	Exclude string
+
+	// Exported limits the generation to the exported functions only.
+	Exported bool
+
+	// Receiver limits the generation to the methods of the type.
+	Receiver string
+
+	// Mocks is the backend, which is used for the generation of mocks for
+	// the interface fields of the structs, empty value disables the mocks.
+	// Mocks require the type-aware parsing.
+	Mocks string
+
+	// Assert is the library, which is used to check the results.
+	Assert string
+
+	// Template is the file or the directory with templates, which
+	// override the blocks of the built-in template.
+	Template string
+
+	// Tags, GOOS and GOARCH select the build variant of the package, files
+	// excluded by the build constraints are not the part of it. Tags are
+	// comma-separated, empty GOOS and GOARCH default to the host ones.
+	Tags   string
+	GOOS   string
+	GOARCH string
+
+	// Kind is the comma-separated list of the generated functions: tests,
+	// benchmarks, fuzz targets and examples.
+	Kind string
+
+	// Seeds is the way the testcases are seeded: by the zero values and the
+	// boundary values of the arguments, by the zero values only, or not at all.
+	Seeds string
+
+	// Context is the value of the context arguments, which are removed from
+	// the args of the testcase: context.Background(), context.TODO(), the
+	// t.Context() or the per-testcase function. With `none` the contexts are
+	// left in the args.
+	Context string
+
+	// Panics selects the functions, which tests expect the panic: the ones
+	// documented to panic, all of them or none.
+	Panics string
+
+	// Naming is the naming policy of the generated tests: one of the presets
+	// or the template with `{Type}` and `{Name}` placeholders. It is used for
+	// the detection of the existing tests too.
+	Naming string
+
+	// TestPatterns are the comma-separated names of the existing tests, which
+	// cover the function, `{Type}` and `{Name}` are replaced by the receiver
+	// type and the function name.
+	TestPatterns string
+
+	// Scan is the scope of the search for the existing tests.
+	Scan string
+
+	// MatchCalls treats the functions called by the existing tests as tested.
+	MatchCalls bool
+
+	// Plugins is the comma-separated list of the plugins, which are enabled,
+	// or disabled, when prefixed with `-`. Plugins are switched by changing
+	// the options, which configure them, like the Mocks or the Context.
+	Plugins string
+
+	// Header is the text, which is written as a comment at the top of
+	// the new test files.
+	Header string
+
+	// DryRun prints the generated files to the stdout instead of writing them.
+	DryRun bool
+
+	// Diff prints the unified diff of the generated files instead of writing them.
+	Diff bool
+
+	// Version is the version of the tool, which is recorded in the header
+	// of the generated files.
+	Version string
+
+	// ShowVersion prints the version of the tool and exits.
+	ShowVersion bool
+
+	// Verbose enables the debug logs, it takes priority over the LogLevel.
+	Verbose bool
+
+	// LogLevel and LogFormat configure the logs, which are written to the stderr.
+	LogLevel  string
+	LogFormat string
+
+	// explicit are the names of the flags, which are set by the user,
+	// they take priority over the configuration file.
+	explicit map[string]struct{}
 }
 
+const (
+	MocksFake     = "fake"
+	MocksGomock   = "gomock"
+	MocksTestify  = "testify"
+	MocksMinimock = "minimock"
+)
+
+var mocksBackends = []string{MocksFake, MocksGomock, MocksTestify, MocksMinimock}
+
+const (
+	AssertRequire = "require"
+	AssertAssert  = "assert"
+	AssertCmp     = "cmp"
+	AssertStd     = "std"
+)
+
+var assertStyles = []string{AssertRequire, AssertAssert, AssertCmp, AssertStd}
+
+const (
+	GenerateTest    = "test"
+	GenerateBench   = "bench"
+	GenerateFuzz    = "fuzz"
+	GenerateExample = "example"
+)
+
+var generateKinds = []string{GenerateTest, GenerateBench, GenerateFuzz, GenerateExample}
+
+const (
+	SeedsBoundary = "boundary"
+	SeedsZero     = "zero"
+	SeedsNone     = "none"
+)
+
+var seedModes = []string{SeedsBoundary, SeedsZero, SeedsNone}
+
+const (
+	ContextBackground = "background"
+	ContextTODO       = "todo"
+	ContextTest       = "test"
+	ContextCase       = "case"
+	ContextNone       = "none"
+)
+
+var contextModes = []string{ContextBackground, ContextTODO, ContextTest, ContextCase, ContextNone}
+
+const (
+	PanicsDoc  = "doc"
+	PanicsAll  = "all"
+	PanicsNone = "none"
+)
+
+var panicsModes = []string{PanicsDoc, PanicsAll, PanicsNone}
+
+const (
+	PluginMocks   = "mocks"
+	PluginContext = "context"
+	PluginPanics  = "panics"
+	PluginSeeds   = "seeds"
+)
+
+var pluginNames = []string{PluginMocks, PluginContext, PluginPanics, PluginSeeds}
+
 func ParseFlags() (*Flags, error) {
 	var f = &Flags{
 		InputFile:  "<from-user>.go",
 		OutputFile: "<from-user>_test.go",
 	}
 
-	flag.StringVar(&f.InputFile, "input", "", "input file")
-	flag.StringVar(&f.OutputFile, "output", "", "output file")
+	defineFlags(f)
 	flag.Parse()
 
+	if f.ShowVersion {
+		return f, nil
+	}
+
+	if err := f.validateOutput(); err != nil {
+		return nil, err
+	}
+
+	f.explicit = make(map[string]struct{})
+	flag.Visit(func(fl *flag.Flag) { f.explicit[fl.Name] = struct{}{} })
+
+	if f.IsPackageInput() {
+		if f.OutputFile != "" {
+			return nil, fmt.Errorf("output file can be specified only for a single input file")
+		}
+
+		// configuration is applied for each file of the package separately,
+		// so only the values from the command line are validated here.
+		if err := f.validate(); err != nil {
+			return nil, err
+		}
+
+		return f, nil
+	}
+
+	if err := f.setFiles(); err != nil {
+		return nil, err
+	}
+
+	if err := f.applyConfig(); err != nil {
+		return nil, fmt.Errorf("apply config: %w", err)
+	}
+
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func defineFlags(f *Flags) {
+	flag.StringVar(&f.InputFile, "input", "", "input file, package directory or dir/... pattern")
+	flag.StringVar(&f.OutputFile, "output", "", "output file")
+	flag.BoolVar(&f.TypeCheck, "typecheck", false, "resolve types of the declarations using go/types")
+	flag.StringVar(&f.Only, "only", "", "generate tests only for functions matching the regex")
+	flag.StringVar(&f.Exclude, "exclude", "", "skip functions matching the regex")
+	flag.BoolVar(&f.Exported, "exported", false, "generate tests only for exported functions")
+	flag.StringVar(&f.Receiver, "receiver", "", "generate tests only for methods of the type")
+	flag.StringVar(&f.Mocks, "mocks", "", "generate mocks for interface fields of the structs: "+strings.Join(mocksBackends, "|"))
+	flag.StringVar(&f.Assert, "assert", AssertRequire, "assertion style: "+strings.Join(assertStyles, "|"))
+	flag.StringVar(&f.Template, "template", "", "template file or directory with templates overriding the built-in one")
+	flag.StringVar(&f.Tags, "tags", "", "comma-separated list of build tags")
+	flag.StringVar(&f.GOOS, "goos", "", "target operating system of the build, defaults to the host one")
+	flag.StringVar(&f.GOARCH, "goarch", "", "target architecture of the build, defaults to the host one")
+	flag.StringVar(&f.Kind, "kind", GenerateTest, "comma-separated kinds of the generated functions: "+strings.Join(generateKinds, ","))
+	flag.StringVar(&f.Seeds, "seeds", SeedsBoundary, "seed testcases of the tests: "+strings.Join(seedModes, "|"))
+	flag.StringVar(&f.Context, "context", ContextBackground, "value of the context arguments: "+strings.Join(contextModes, "|"))
+	flag.StringVar(&f.Panics, "panics", PanicsDoc, "functions which tests expect the panic: "+strings.Join(panicsModes, "|"))
+	flag.StringVar(&f.Naming, "naming", NamingDefault, "naming policy of the tests: "+strings.Join(namingPolicies(), "|")+" or a template with {Type} and {Name}")
+	flag.StringVar(&f.TestPatterns, "test-patterns", DefaultTestPatterns, "comma-separated patterns of the existing test names, {Type} and {Name} are replaced")
+	flag.StringVar(&f.Scan, "scan", ScanOutput, "where to look for the existing tests: "+strings.Join(scanScopes, "|"))
+	flag.BoolVar(&f.MatchCalls, "match-calls", false, "treat functions called by the existing tests as tested")
+	flag.StringVar(&f.Plugins, "plugins", "", "comma-separated plugins to enable or, prefixed with -, to disable: "+strings.Join(pluginNames, ","))
+	flag.BoolVar(&f.DryRun, "dry-run", false, "print the generated files instead of writing them")
+	flag.BoolVar(&f.Diff, "diff", false, "print the diff of the generated files instead of writing them")
+	flag.BoolVar(&f.ShowVersion, "version", false, "print the version and exit")
+	flag.BoolVar(&f.Verbose, "v", false, "verbose output, same as -log-level=debug")
+	flag.StringVar(&f.LogLevel, "log-level", "warn", "log level: debug|info|warn|error")
+	flag.StringVar(&f.LogFormat, "log-format", LogFormatText, "log format: "+strings.Join(logFormats, "|"))
+}
+
+// validateOutput checks the options of the logs and the output mode,
+// which are set only from the command line.
+func (f *Flags) validateOutput() error {
+	if err := parseLogLevel(f.LogLevel); err != nil {
+		return err
+	}
+
+	if err := oneOf("log format", f.LogFormat, logFormats); err != nil {
+		return err
+	}
+
+	if f.DryRun && f.Diff {
+		return fmt.Errorf("dry-run and diff can't be used together")
+	}
+
+	return nil
+}
+
+// setFiles checks the extensions of the single input file and the
+// output file, which defaults to the test file next to the input one.
+func (f *Flags) setFiles() error {
 	if !strings.HasSuffix(f.InputFile, ".go") {
-		return nil, fmt.Errorf("input file must have .go extension")
+		return fmt.Errorf("input file must have .go extension")
 	}
 
 	if f.OutputFile == "" {
-		f.OutputFile = fmt.Sprintf("%s_test.go", strings.TrimSuffix(f.InputFile, ".go"))
+		f.OutputFile = defaultOutputFile(f.InputFile)
 	} else if !strings.HasSuffix(f.OutputFile, "_test.go") {
-		return nil, fmt.Errorf("output file must have _test.go extension")
+		return fmt.Errorf("output file must have _test.go extension")
 	}
 
-	return f, nil
+	return nil
+}
+
+// validate checks the values of the options, which can be set both
+// from the command line and the configuration file.
+func (f *Flags) validate() error {
+	for _, validate := range []func() error{
+		f.validateGeneration,
+		f.validateTestcases,
+		f.validateExisting,
+		f.validatePlugins,
+	} {
+		if err := validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateGeneration checks the options, which select the functions
+// and the layout of the generated ones.
+func (f *Flags) validateGeneration() error {
+	if _, err := newFnFilter(f); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+
+	for _, kind := range f.Kinds() {
+		if err := oneOf("kind", kind, generateKinds); err != nil {
+			return err
+		}
+	}
+
+	if f.Template != "" {
+		if _, err := os.Stat(f.Template); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateTestcases checks the options, which change the content of
+// the generated tests, empty values are the defaults.
+func (f *Flags) validateTestcases() error {
+	if err := oneOf("assertion style", f.Assert, assertStyles); err != nil {
+		return err
+	}
+
+	for _, o := range []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"mocks backend", f.Mocks, mocksBackends},
+		{"seeds mode", f.Seeds, seedModes},
+		{"context mode", f.Context, contextModes},
+		{"panics mode", f.Panics, panicsModes},
+	} {
+		if o.value == "" {
+			continue
+		}
+
+		if err := oneOf(o.name, o.value, o.allowed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateExisting checks the options of the existing tests detection.
+func (f *Flags) validateExisting() error {
+	if _, err := namingTemplate(f.Naming); err != nil {
+		return err
+	}
+
+	if f.Scan != "" {
+		return oneOf("scan scope", f.Scan, scanScopes)
+	}
+
+	return nil
+}
+
+func (f *Flags) validatePlugins() error {
+	return checkPlugins(f.Plugins)
+}
+
+// checkPlugins checks the names of the switched plugins.
+func checkPlugins(plugins string) error {
+	for _, p := range pluginSwitches(plugins) {
+		if err := oneOf("plugin", p.name, pluginNames); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pluginSwitch enables or disables the plugin.
+type pluginSwitch struct {
+	name    string
+	enabled bool
+}
+
+func pluginSwitches(plugins string) []pluginSwitch {
+	return lo.FilterMap(strings.Split(plugins, ","), func(p string, _ int) (pluginSwitch, bool) {
+		p = strings.TrimSpace(p)
+		name, disabled := strings.CutPrefix(p, "-")
+		return pluginSwitch{name: name, enabled: !disabled}, p != ""
+	})
+}
+
+// switchPlugins enables and disables the plugins by the options, which
+// configure them: enabled plugins get the default options, when they are
+// disabled, and the disabled ones get the options, which turn them off.
+// Options explicitly set by the user are not changed, unless force is set.
+func (f *Flags) switchPlugins(plugins string, force bool) {
+	var options = map[string]struct {
+		dst               *string
+		enabled, disabled string
+	}{
+		PluginMocks:   {&f.Mocks, MocksFake, ""},
+		PluginContext: {&f.Context, ContextBackground, ContextNone},
+		PluginPanics:  {&f.Panics, PanicsDoc, PanicsNone},
+		PluginSeeds:   {&f.Seeds, SeedsBoundary, SeedsNone},
+	}
+
+	for _, p := range pluginSwitches(plugins) {
+		o, known := options[p.name]
+		if _, explicit := f.explicit[p.name]; !known || explicit && !force {
+			continue
+		}
+
+		switch {
+		case !p.enabled:
+			*o.dst = o.disabled
+		case *o.dst == o.disabled:
+			*o.dst = o.enabled
+		}
+	}
+}
+
+func oneOf(name, value string, allowed []string) error {
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("unknown %s: %s", name, value)
+	}
+
+	return nil
+}
+
+// typesRequired reports whether the type-aware parsing must be done,
+// because some features can't work without it.
+func (f *Flags) typesRequired() bool {
+	return f.TypeCheck || f.Mocks != ""
+}
+
+// Kinds returns the kinds of the generated functions, only the tests
+// are generated by default.
+func (f *Flags) Kinds() []string {
+	var kinds = lo.FilterMap(strings.Split(f.Kind, ","), func(kind string, _ int) (string, bool) {
+		kind = strings.TrimSpace(kind)
+		return kind, kind != ""
+	})
+
+	if len(kinds) == 0 {
+		return []string{GenerateTest}
+	}
+
+	return lo.Uniq(kinds)
+}
+
+// buildContext returns the context, which selects the files of the
+// package matching the build variant.
+func (f *Flags) buildContext() *build.Context {
+	var ctx = build.Default
+	if f.GOOS != "" {
+		ctx.GOOS = f.GOOS
+	}
+
+	if f.GOARCH != "" {
+		ctx.GOARCH = f.GOARCH
+	}
+
+	ctx.BuildTags = lo.FilterMap(strings.Split(f.Tags, ","), func(tag string, _ int) (string, bool) {
+		tag = strings.TrimSpace(tag)
+		return tag, tag != ""
+	})
+
+	return &ctx
+}
+
+// Preview reports whether the generated files are printed instead of
+// being written to the disk.
+func (f *Flags) Preview() bool {
+	return f.DryRun || f.Diff
+}
+
+// Options returns the options, which affect the generated code, in the
+// command line format, the default values are omitted.
+func (f *Flags) Options() string {
+	var opts = make([]string, 0)
+	if f.TypeCheck {
+		opts = append(opts, "-typecheck")
+	}
+
+	if f.Exported {
+		opts = append(opts, "-exported")
+	}
+
+	if f.MatchCalls {
+		opts = append(opts, "-match-calls")
+	}
+
+	for _, o := range []struct{ name, value, defaultValue string }{
+		{"only", f.Only, ""},
+		{"exclude", f.Exclude, ""},
+		{"receiver", f.Receiver, ""},
+		{"mocks", f.Mocks, ""},
+		{"assert", f.Assert, AssertRequire},
+		{"tags", f.Tags, ""},
+		{"goos", f.GOOS, ""},
+		{"goarch", f.GOARCH, ""},
+		{"kind", f.Kind, GenerateTest},
+		{"seeds", f.Seeds, SeedsBoundary},
+		{"context", f.Context, ContextBackground},
+		{"panics", f.Panics, PanicsDoc},
+		{"naming", f.Naming, NamingDefault},
+		{"test-patterns", f.TestPatterns, DefaultTestPatterns},
+		{"scan", f.Scan, ScanOutput},
+	} {
+		// empty values are treated as the defaults, when the flags
+		// are created without parsing the command line.
+		if o.value == o.defaultValue || o.value == "" {
+			continue
+		}
+
+		var value = o.value
+		if strings.ContainsAny(value, " \t\"'") {
+			value = strconv.Quote(value)
+		}
+
+		opts = append(opts, fmt.Sprintf("-%s=%s", o.name, value))
+	}
+
+	return strings.Join(opts, " ")
+}
+
+// MocksFile returns the path of the companion file with mocks,
+// which is stored next to the output file.
+func (f *Flags) MocksFile() string {
+	return filepath.Join(filepath.Dir(f.OutputFile), "mocks_test.go")
+}
+
+func defaultOutputFile(inputFile string) string {
+	return fmt.Sprintf("%s_test.go", strings.TrimSuffix(inputFile, ".go"))
+}
+
+// ForFile returns a copy of the flags, which are used for the generation
+// of the tests for a single input file from the package, with the
+// configuration of the file applied.
+func (f *Flags) ForFile(inputFile string) (*Flags, error) {
+	var ff = *f
+	if ff.InputFile != inputFile {
+		ff.InputFile = inputFile
+		ff.OutputFile = defaultOutputFile(inputFile)
+	}
+
+	if err := ff.applyConfig(); err != nil {
+		return nil, fmt.Errorf("apply config: %w", err)
+	}
+
+	if err := ff.validate(); err != nil {
+		return nil, err
+	}
+
+	return &ff, nil
 }
@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// ConfigFile is the name of the project configuration file, which is
+// searched by walking up from the directory of the input file.
+const ConfigFile = ".ggt.yaml"
+
+// options are the generation settings, which can be declared in the
+// configuration file, nil values are not changing the flags.
+//
+// Keys are named the same way as the flags, so the flags, which are
+// explicitly set by the user, can be detected.
+type options struct {
+	TypeCheck *bool   `yaml:"typecheck"`
+	Only      *string `yaml:"only"`
+	Exclude   *string `yaml:"exclude"`
+	Exported  *bool   `yaml:"exported"`
+	Receiver  *string `yaml:"receiver"`
+	Mocks     *string `yaml:"mocks"`
+	Assert    *string `yaml:"assert"`
+	Template  *string `yaml:"template"`
+	Header    *string `yaml:"header"`
+	Tags      *string `yaml:"tags"`
+	GOOS      *string `yaml:"goos"`
+	GOARCH    *string `yaml:"goarch"`
+
+	Kind         *string `yaml:"kind"`
+	Seeds        *string `yaml:"seeds"`
+	Context      *string `yaml:"context"`
+	Panics       *string `yaml:"panics"`
+	Naming       *string `yaml:"naming"`
+	TestPatterns *string `yaml:"test-patterns"`
+	Scan         *string `yaml:"scan"`
+	MatchCalls   *bool   `yaml:"match-calls"`
+	Plugins      *string `yaml:"plugins"`
+}
+
+// override are the options, which are applied only to the files
+// inside the directory.
+type override struct {
+	options `yaml:",inline"`
+
+	// Path is the directory relative to the configuration file.
+	Path string `yaml:"path"`
+}
+
+// config is the content of the configuration file.
+//
+//	assert: std
+//	mocks: fake
+//	overrides:
+//	  - path: internal/legacy
+//	    assert: require
+type config struct {
+	options   `yaml:",inline"`
+	Overrides []*override `yaml:"overrides"`
+
+	// dir is the directory of the configuration file, all the paths
+	// declared in the configuration are relative to it.
+	dir string
+}
+
+// findConfig returns the path of the closest configuration file for
+// the directory or an empty string, if there is no such file.
+func findConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolve directory: %w", err)
+	}
+
+	for {
+		var path = filepath.Join(dir, ConfigFile)
+		if _, err = os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("stat config: %w", err)
+		}
+
+		var parent = filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var (
+		c       = &config{dir: filepath.Dir(path)}
+		decoder = yaml.NewDecoder(bytes.NewReader(content))
+	)
+
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	// unknown plugins are skipped by the switching, so their names
+	// are checked here.
+	for _, o := range append([]*options{&c.options}, lo.Map(c.Overrides, func(o *override, _ int) *options {
+		return &o.options
+	})...) {
+		if o.Plugins == nil {
+			continue
+		}
+
+		if err = checkPlugins(*o.Plugins); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+
+	return c, nil
+}
+
+// optionsFor returns the options, which must be applied to the file: the
+// defaults go first, followed by the overrides from the outer directories
+// to the inner ones.
+func (c *config) optionsFor(file string) ([]*options, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("resolve file: %w", err)
+	}
+
+	var (
+		dir       = filepath.Dir(abs)
+		result    = []*options{c.resolved(&c.options)}
+		overrides = slices.Clone(c.Overrides)
+	)
+
+	slices.SortStableFunc(overrides, func(a, b *override) int {
+		return len(filepath.Clean(a.Path)) - len(filepath.Clean(b.Path))
+	})
+
+	for _, o := range overrides {
+		rel, relErr := filepath.Rel(filepath.Join(c.dir, o.Path), dir)
+		if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		result = append(result, c.resolved(&o.options))
+	}
+
+	return result, nil
+}
+
+// resolved returns the copy of the options with the paths resolved
+// relative to the configuration file.
+func (c *config) resolved(o *options) *options {
+	var r = *o
+	if r.Template != nil && *r.Template != "" && !filepath.IsAbs(*r.Template) {
+		var template = filepath.Join(c.dir, *r.Template)
+		r.Template = &template
+	}
+
+	return &r
+}
+
+// applyConfig applies the closest configuration file of the input file to
+// the flags, keeping the values of the flags, which are explicitly set.
+// Plugins switched in the command line are applied last.
+func (f *Flags) applyConfig() error {
+	if err := f.applyConfigFile(); err != nil {
+		return err
+	}
+
+	if _, ok := f.explicit["plugins"]; ok {
+		f.switchPlugins(f.Plugins, true)
+	}
+
+	return nil
+}
+
+func (f *Flags) applyConfigFile() error {
+	path, err := findConfig(filepath.Dir(f.InputFile))
+	if err != nil || path == "" {
+		return err
+	}
+
+	c, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	opts, err := c.optionsFor(f.InputFile)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range opts {
+		f.applyOptions(o)
+	}
+
+	return nil
+}
+
+func (f *Flags) applyOptions(o *options) {
+	setValue(f, "typecheck", &f.TypeCheck, o.TypeCheck)
+	setValue(f, "only", &f.Only, o.Only)
+	setValue(f, "exclude", &f.Exclude, o.Exclude)
+	setValue(f, "exported", &f.Exported, o.Exported)
+	setValue(f, "receiver", &f.Receiver, o.Receiver)
+	setValue(f, "mocks", &f.Mocks, o.Mocks)
+	setValue(f, "assert", &f.Assert, o.Assert)
+	setValue(f, "template", &f.Template, o.Template)
+	setValue(f, "header", &f.Header, o.Header)
+	setValue(f, "tags", &f.Tags, o.Tags)
+	setValue(f, "goos", &f.GOOS, o.GOOS)
+	setValue(f, "goarch", &f.GOARCH, o.GOARCH)
+	setValue(f, "kind", &f.Kind, o.Kind)
+	setValue(f, "seeds", &f.Seeds, o.Seeds)
+	setValue(f, "context", &f.Context, o.Context)
+	setValue(f, "panics", &f.Panics, o.Panics)
+	setValue(f, "naming", &f.Naming, o.Naming)
+	setValue(f, "test-patterns", &f.TestPatterns, o.TestPatterns)
+	setValue(f, "scan", &f.Scan, o.Scan)
+	setValue(f, "match-calls", &f.MatchCalls, o.MatchCalls)
+
+	// plugins are switched by each of the options in order, so the inner
+	// overrides can enable the plugins disabled by the outer ones.
+	if _, ok := f.explicit["plugins"]; !ok && o.Plugins != nil {
+		f.switchPlugins(*o.Plugins, false)
+	}
+}
+
+func setValue[T any](f *Flags, name string, dst *T, value *T) {
+	if value == nil {
+		return
+	}
+
+	if _, ok := f.explicit[name]; ok {
+		return
+	}
+
+	*dst = *value
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flags_applyConfig(t *testing.T) {
+	var root = t.TempDir()
+	for name, content := range map[string]string{
+		ConfigFile: "assert: std\n" +
+			"mocks: fake\n" +
+			"template: tmpl\n" +
+			"overrides:\n" +
+			"  - path: legacy/inner\n" +
+			"    assert: cmp\n" +
+			"  - path: legacy\n" +
+			"    assert: assert\n" +
+			"    mocks: \"\"\n",
+		"svc/svc.go":            "package svc\n",
+		"legacy/legacy.go":      "package legacy\n",
+		"legacy/inner/inner.go": "package inner\n",
+		"legacyx/legacyx.go":    "package legacyx\n",
+		"nested/" + ConfigFile:  "exported: true\n",
+		"nested/nested.go":      "package nested\n",
+		"broken/" + ConfigFile:  "unknown: true\n",
+		"broken/broken.go":      "package broken\n",
+		"plugins/" + ConfigFile: "panics: all\n" +
+			"plugins: -context,-panics\n" +
+			"overrides:\n" +
+			"  - path: inner\n" +
+			"    plugins: panics,mocks\n",
+		"plugins/plugins.go":     "package plugins\n",
+		"plugins/inner/inner.go": "package inner\n",
+		"unknown/" + ConfigFile:  "plugins: -cache\n",
+		"unknown/unknown.go":     "package unknown\n",
+	} {
+		var path = filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	type args struct {
+		flags *Flags
+	}
+
+	type want struct {
+		want    *Flags
+		wantErr require.ErrorAssertionFunc
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "defaults",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "svc/svc.go"), Assert: AssertRequire}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "svc/svc.go"),
+					Assert:    AssertStd,
+					Mocks:     MocksFake,
+					Template:  filepath.Join(root, "tmpl"),
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "explicit_flags_take_priority",
+			args: args{flags: &Flags{
+				InputFile: filepath.Join(root, "svc/svc.go"),
+				Assert:    AssertRequire,
+				explicit:  map[string]struct{}{"assert": {}},
+			}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "svc/svc.go"),
+					Assert:    AssertRequire,
+					Mocks:     MocksFake,
+					Template:  filepath.Join(root, "tmpl"),
+					explicit:  map[string]struct{}{"assert": {}},
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "directory_override",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "legacy/legacy.go")}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "legacy/legacy.go"),
+					Assert:    AssertAssert,
+					Template:  filepath.Join(root, "tmpl"),
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "inner_override_wins",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "legacy/inner/inner.go")}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "legacy/inner/inner.go"),
+					Assert:    AssertCmp,
+					Template:  filepath.Join(root, "tmpl"),
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "directory_with_common_prefix",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "legacyx/legacyx.go")}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "legacyx/legacyx.go"),
+					Assert:    AssertStd,
+					Mocks:     MocksFake,
+					Template:  filepath.Join(root, "tmpl"),
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "closest_config",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "nested/nested.go")}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "nested/nested.go"),
+					Exported:  true,
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "plugins_disabled",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "plugins/plugins.go"), Context: ContextTest}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "plugins/plugins.go"),
+					Context:   ContextNone,
+					Panics:    PanicsNone,
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "plugins_enabled_by_override",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "plugins/inner/inner.go"), Context: ContextTest}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "plugins/inner/inner.go"),
+					Mocks:     MocksFake,
+					Context:   ContextNone,
+					Panics:    PanicsDoc,
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "plugins_flag_applied_last",
+			args: args{flags: &Flags{
+				InputFile: filepath.Join(root, "plugins/inner/inner.go"),
+				Context:   ContextTest,
+				Plugins:   "context,-panics",
+				explicit:  map[string]struct{}{"plugins": {}},
+			}},
+			want: want{
+				want: &Flags{
+					InputFile: filepath.Join(root, "plugins/inner/inner.go"),
+					Context:   ContextTest,
+					Panics:    PanicsNone,
+					Plugins:   "context,-panics",
+					explicit:  map[string]struct{}{"plugins": {}},
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "unknown_plugin",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "unknown/unknown.go")}},
+			want: want{
+				want:    &Flags{InputFile: filepath.Join(root, "unknown/unknown.go")},
+				wantErr: require.Error,
+			},
+		},
+		{
+			name: "unknown_field",
+			args: args{flags: &Flags{InputFile: filepath.Join(root, "broken/broken.go")}},
+			want: want{
+				want:    &Flags{InputFile: filepath.Join(root, "broken/broken.go")},
+				wantErr: require.Error,
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr := tt.args.flags.applyConfig()
+
+			require.Equal(t, tt.want.want, tt.args.flags)
+			tt.want.wantErr(t, gotErr)
+		})
+	}
+}
@@ -2,6 +2,9 @@ package internal
 
 import (
 	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
 	"strings"
 
 	"github.com/fadyat/ggt/internal/lo"
@@ -9,14 +12,40 @@ import (
 
 type File struct {
 	PackageName string
-	Imports     []string
-	Functions   []*Fn
+
+	// PackagePath is the import path of the package, it is set only
+	// when the type-aware parsing is enabled.
+	PackagePath string
+
+	// Imports are stored in the `[name] "path"` format, the same way
+	// as they are written in the source code.
+	Imports   []string
+	Functions []*Fn
+
+	// BuildConstraint is the `//go:build` line of the file, the tests
+	// are built only together with the file.
+	BuildConstraint string
+
+	// GoVersion is the version from the go directive of the module,
+	// it is empty, when the file is not a part of any module.
+	GoVersion string
 }
 
+// Struct is the named type, which is used as a receiver of the methods.
 type Struct struct {
 	Name     string
 	Generics []*Identifier
 	Fields   []*Identifier
+
+	// Underlying is the type expression of the non-struct types, like
+	// `[]string` for `type list []string`, it is empty for the structs.
+	Underlying string
+}
+
+// IsStruct reports whether the type is a struct, methods of other types
+// get the receiver value from the testcase instead of the fields.
+func (s *Struct) IsStruct() bool {
+	return s.Underlying == ""
 }
 
 func newStruct(name string) *Struct {
@@ -35,17 +64,109 @@ type Fn struct {
 	// Struct is the type definition of the receiver with fields
 	// required for correct method generation.
 	Struct *Struct
+
+	// Kinds are the kinds of the generated functions, which are missing
+	// for the function, like the test or the benchmark.
+	Kinds []string
+
+	// Panics reports whether the function is documented to panic: its doc
+	// comment mentions the panic, or it is named after the Must convention.
+	Panics bool
+
+	// naming is the template of the test name, the default
+	// naming policy is used, when it is empty.
+	naming string
+}
+
+// HasKind reports whether the function of the kind must be generated,
+// only the test is generated, when the kinds are not set.
+func (f *Fn) HasKind(kind string) bool {
+	if len(f.Kinds) == 0 {
+		return kind == GenerateTest
+	}
+
+	return slices.Contains(f.Kinds, kind)
+}
+
+// BenchmarkName returns the name of the benchmark, which follows
+// the naming policy of the tests.
+func (f *Fn) BenchmarkName() string {
+	return "Benchmark" + strings.TrimPrefix(f.TestName(), "Test")
 }
 
+// FuzzName returns the name of the fuzz target, which follows
+// the naming policy of the tests.
+func (f *Fn) FuzzName() string {
+	return "Fuzz" + strings.TrimPrefix(f.TestName(), "Test")
+}
+
+// ExampleName returns the name of the example, examples are named after
+// the documented identifier, like `ExampleType_Method`, so the naming
+// policy is not used for them.
+func (f *Fn) ExampleName() string {
+	if f.Receiver == nil {
+		return "Example" + f.Name
+	}
+
+	return fmt.Sprintf("Example%s_%s", f.structTypeBasedOnReceiver(), f.Name)
+}
+
+// Exemplifiable reports whether the example can be generated for the
+// function: it must be a part of the exported API, the generic functions
+// are skipped, because the placeholder values can't be declared for them.
+func (f *Fn) Exemplifiable() bool {
+	if !ast.IsExported(f.Name) || len(f.Generics) > 0 {
+		return false
+	}
+
+	return f.Receiver == nil || ast.IsExported(f.structTypeBasedOnReceiver())
+}
+
+// fuzzableTypes are the types of the arguments, which are supported
+// by the fuzzing engine.
+var fuzzableTypes = map[string]struct{}{
+	"string": {}, "[]byte": {}, "bool": {}, "byte": {}, "rune": {},
+	"int": {}, "int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint": {}, "uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"float32": {}, "float64": {},
+}
+
+// Fuzzable reports whether the fuzz target can be generated for the
+// function: it must be a plain function, all the arguments of which are
+// supported by the fuzzing engine.
+func (f *Fn) Fuzzable() bool {
+	if f.Receiver != nil || len(f.Generics) > 0 || len(f.Args) == 0 {
+		return false
+	}
+
+	for _, arg := range f.Args {
+		if _, ok := fuzzableTypes[arg.Type]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TestName returns the name of the test for the function, according
+// to the naming policy.
 func (f *Fn) TestName() string {
-	var sb strings.Builder
-	sb.WriteString("Test_")
-	if f.Struct != nil {
-		sb.WriteString(fmt.Sprintf("%s_", f.Struct.Name))
+	var naming = f.naming
+	if naming == "" {
+		naming = namingPresets[NamingDefault]
+	}
+
+	return testName(naming, f)
+}
+
+// FullName returns the name of the function, prefixed with the
+// receiver type for methods.
+func (f *Fn) FullName() string {
+	if f.Receiver == nil {
+		return f.Name
 	}
 
-	sb.WriteString(f.Name)
-	return sb.String()
+	return fmt.Sprintf("%s.%s", f.structTypeBasedOnReceiver(), f.Name)
 }
 
 func newFn(name string) *Fn {
@@ -101,13 +222,40 @@ func (f *Fn) structTypeBasedOnReceiver() string {
 		return ""
 	}
 
-	// removing the pointer from the receiver type
-	return strings.TrimPrefix(f.Receiver.Type, "*")
+	// removing the pointer and the type parameters from the receiver type,
+	// `*List[T]` becomes `List`.
+	return strings.TrimRight(stripTypeParams(strings.TrimLeft(f.Receiver.Type, "*(")), ")")
+}
+
+// setStruct sets the resolved receiver type of the method, the type
+// parameters of the generic receiver get the constraints of the type.
+func (f *Fn) setStruct(s *Struct) {
+	f.Struct = s
+	for i, param := range f.Generics {
+		param.Type = "any"
+		if i < len(s.Generics) && len(f.Generics) == len(s.Generics) {
+			param.Type = s.Generics[i].Type
+		}
+	}
+}
+
+// stripTypeParams removes the type parameters from the type name,
+// `List[T]` becomes `List`.
+func stripTypeParams(name string) string {
+	if idx := strings.Index(name, "["); idx != -1 {
+		return name[:idx]
+	}
+
+	return name
 }
 
 type Identifier struct {
 	Name string
 	Type string
+
+	// Info is the resolved type information, which is available only
+	// when the type-aware parsing is enabled and the type is resolved.
+	Info *TypeInfo
 }
 
 func newIdentifier(name, typ string) *Identifier {
@@ -116,3 +264,49 @@ func newIdentifier(name, typ string) *Identifier {
 		Type: typ,
 	}
 }
+
+// TypeKind is a kind of the underlying type of the identifier.
+type TypeKind string
+
+const (
+	KindBasic     TypeKind = "basic"
+	KindStruct    TypeKind = "struct"
+	KindInterface TypeKind = "interface"
+	KindPointer   TypeKind = "pointer"
+	KindSlice     TypeKind = "slice"
+	KindArray     TypeKind = "array"
+	KindMap       TypeKind = "map"
+	KindChan      TypeKind = "chan"
+	KindFunc      TypeKind = "func"
+	KindTypeParam TypeKind = "type_param"
+)
+
+// TypeInfo is the type information of the identifier, resolved by the go/types.
+type TypeInfo struct {
+	Kind TypeKind
+
+	// Underlying is the string representation of the underlying type,
+	// with the package qualified by its path.
+	Underlying string
+
+	// PkgPath is the path of the package, where the named type is declared.
+	// Pointers are dereferenced, so for `*bytes.Buffer` it will be `bytes`.
+	// Empty for predeclared and unnamed types.
+	PkgPath string
+
+	// Alias is true, when the type is declared as an alias.
+	Alias bool
+
+	Type types.Type
+}
+
+// IsInterface reports whether the identifier is a non-empty interface,
+// which can be replaced with a mock.
+func (i *TypeInfo) IsInterface() bool {
+	if i == nil || i.Kind != KindInterface {
+		return false
+	}
+
+	iface, ok := i.Type.Underlying().(*types.Interface)
+	return ok && iface.NumMethods() > 0
+}
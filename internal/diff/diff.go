@@ -0,0 +1,305 @@
+// Package diff implements the line-based unified diff, which is used to
+// preview the changes of the generated files.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+// context is the number of unchanged lines around the changes.
+const context = 3
+
+type kind int
+
+const (
+	equal kind = iota
+	deleted
+	inserted
+)
+
+type edit struct {
+	kind kind
+	line string
+}
+
+// Unified returns the unified diff between the old and new contents,
+// empty result means that the contents are equal.
+func Unified(oldName, newName string, oldContent, newContent []byte) []byte {
+	var edits = myers(splitLines(oldContent), splitLines(newContent))
+	if !slices.ContainsFunc(edits, func(e edit) bool { return e.kind != equal }) {
+		return nil
+	}
+
+	// positions of the edits in the old and new contents
+	var (
+		oldPos = make([]int, len(edits)+1)
+		newPos = make([]int, len(edits)+1)
+	)
+
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.kind != inserted {
+			oldPos[i+1]++
+		}
+
+		if e.kind != deleted {
+			newPos[i+1]++
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	for _, h := range hunks(edits) {
+		b.WriteString(fmt.Sprintf(
+			"@@ -%s +%s @@\n",
+			hunkRange(oldPos[h[0]], oldPos[h[1]]-oldPos[h[0]]),
+			hunkRange(newPos[h[0]], newPos[h[1]]-newPos[h[0]]),
+		))
+
+		for _, e := range edits[h[0]:h[1]] {
+			b.WriteString(map[kind]string{equal: " ", deleted: "-", inserted: "+"}[e.kind])
+			b.WriteString(e.line)
+			if len(e.line) == 0 || e.line[len(e.line)-1] != '\n' {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return b.Bytes()
+}
+
+// hunkRange formats the range of the hunk in the same way as GNU diff:
+// the length is omitted for a single line, empty ranges point to the
+// line before them.
+func hunkRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
+
+// hunks returns the ranges of the edits, which contain the changes
+// with the surrounding context, close changes are merged together.
+func hunks(edits []edit) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(edits); {
+		if edits[i].kind == equal {
+			i++
+			continue
+		}
+
+		var start, end = max(0, i-context), i
+		for end < len(edits) {
+			if edits[end].kind != equal {
+				end++
+				continue
+			}
+
+			var run = end
+			for run < len(edits) && edits[run].kind == equal {
+				run++
+			}
+
+			if run == len(edits) || run-end > 2*context {
+				break
+			}
+
+			end = run
+		}
+
+		i = min(len(edits), end+context)
+		result = append(result, [2]int{start, i})
+	}
+
+	return result
+}
+
+// splitLines splits the content into the lines, keeping the line endings.
+func splitLines(content []byte) []string {
+	var lines = make([]string, 0)
+	for len(content) > 0 {
+		var idx = bytes.IndexByte(content, '\n')
+		if idx == -1 {
+			lines = append(lines, string(content))
+			break
+		}
+
+		lines = append(lines, string(content[:idx+1]))
+		content = content[idx+1:]
+	}
+
+	return lines
+}
+
+// myers returns the shortest edit script, which transforms a into b.
+//
+// It is an implementation of the Myers O(ND) algorithm, which is fast for
+// the similar inputs, like the files with the appended tests. Common prefix
+// and suffix are skipped before the search, so the appended lines are found
+// without it.
+func myers(a, b []string) []edit {
+	var prefix, suffix = commonAffixes(a, b)
+
+	var edits = make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{kind: equal, line: line})
+	}
+
+	var (
+		oldLines = a[prefix : len(a)-suffix]
+		newLines = b[prefix : len(b)-suffix]
+	)
+
+	switch {
+	case len(oldLines) == 0:
+		edits = appendLines(edits, inserted, newLines)
+	case len(newLines) == 0:
+		edits = appendLines(edits, deleted, oldLines)
+	default:
+		edits = append(edits, backtrack(oldLines, newLines, forward(oldLines, newLines))...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{kind: equal, line: line})
+	}
+
+	return edits
+}
+
+// commonAffixes returns the lengths of the common prefix and suffix of
+// the lines, which don't overlap.
+func commonAffixes(a, b []string) (int, int) {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return prefix, suffix
+}
+
+func appendLines(edits []edit, kind kind, lines []string) []edit {
+	for _, line := range lines {
+		edits = append(edits, edit{kind: kind, line: line})
+	}
+
+	return edits
+}
+
+// frontier contains the furthest reaching x on the diagonals k from -d to d
+// after the step d of the search.
+type frontier []int
+
+func (f frontier) x(k int) int {
+	return f[k+len(f)/2]
+}
+
+// down reports whether the path reaches the diagonal k on the step d from
+// the diagonal k+1 by the insertion, otherwise it comes from k-1 by the deletion.
+func down(prev frontier, k, d int) bool {
+	return k == -d || (k != d && prev.x(k-1) < prev.x(k+1))
+}
+
+// startX returns the x on the diagonal k after the edit of the step d.
+func startX(prev frontier, k, d int) int {
+	switch {
+	case d == 0:
+		return 0
+	case down(prev, k, d):
+		return prev.x(k + 1)
+	default:
+		return prev.x(k-1) + 1
+	}
+}
+
+// snake follows the diagonal from (x, y), while the lines are equal.
+func snake(a, b []string, x, y int) int {
+	for x < len(a) && y < len(b) && a[x] == b[y] {
+		x++
+		y++
+	}
+
+	return x
+}
+
+// forward returns the frontiers of all the steps of the search, only the
+// reachable diagonals are stored, so the memory is O(D^2).
+func forward(a, b []string) []frontier {
+	var trace []frontier
+	for d := 0; ; d++ {
+		var (
+			prev = lastFrontier(trace)
+			cur  = make(frontier, 2*d+1)
+		)
+
+		for k := -d; k <= d; k += 2 {
+			var x = startX(prev, k, d)
+			x = snake(a, b, x, x-k)
+			cur[k+d] = x
+			if x >= len(a) && x-k >= len(b) {
+				return append(trace, cur)
+			}
+		}
+
+		trace = append(trace, cur)
+	}
+}
+
+func lastFrontier(trace []frontier) frontier {
+	if len(trace) == 0 {
+		return nil
+	}
+
+	return trace[len(trace)-1]
+}
+
+// backtrack restores the edits from the end of the search to its start.
+func backtrack(a, b []string, trace []frontier) []edit {
+	var (
+		edits = make([]edit, 0, len(a)+len(b))
+		x, y  = len(a), len(b)
+	)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		var (
+			prev  = trace[d-1]
+			k     = x - y
+			prevK = k - 1
+		)
+
+		if down(prev, k, d) {
+			prevK = k + 1
+		}
+
+		var prevX, prevY = prev.x(prevK), prev.x(prevK) - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{kind: equal, line: a[x-1]})
+			x, y = x-1, y-1
+		}
+
+		if x == prevX {
+			edits = append(edits, edit{kind: inserted, line: b[y-1]})
+		} else {
+			edits = append(edits, edit{kind: deleted, line: a[x-1]})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	// the snake of the first step is the common start of the lines
+	for ; x > 0; x-- {
+		edits = append(edits, edit{kind: equal, line: a[x-1]})
+	}
+
+	slices.Reverse(edits)
+	return edits
+}
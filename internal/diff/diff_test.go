@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Unified(t *testing.T) {
+	type args struct {
+		old string
+		new string
+	}
+
+	type want struct {
+		want string
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "equal",
+			args: args{old: "a\nb\n", new: "a\nb\n"},
+			want: want{want: ""},
+		},
+		{
+			name: "new_file",
+			args: args{old: "", new: "a\nb\n"},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		},
+		{
+			name: "appended_lines",
+			args: args{old: "1\n2\n3\n4\n5\n", new: "1\n2\n3\n4\n5\n6\n"},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -3,3 +3,4 @@\n 3\n 4\n 5\n+6\n"},
+		},
+		{
+			name: "separate_hunks",
+			args: args{
+				old: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+				new: "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n" +
+				"@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n"},
+		},
+		{
+			name: "changed_line",
+			args: args{old: "a\nb\nc\n", new: "a\nx\nc\n"},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		},
+		{
+			name: "deleted_file",
+			args: args{old: "a\nb\n", new: ""},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		},
+		{
+			name: "interleaved_changes",
+			args: args{old: "a\nb\nc\nd\ne\n", new: "x\nb\ny\nd\nz\n"},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n-a\n+x\n b\n-c\n+y\n d\n-e\n+z\n"},
+		},
+		{
+			name: "no_newline_at_end",
+			args: args{old: "a", new: "a\n"},
+			want: want{want: "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a/f", "b/f", []byte(tt.args.old), []byte(tt.args.new))
+			require.Equal(t, tt.want.want, string(got))
+		})
+	}
+}
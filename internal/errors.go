@@ -4,4 +4,8 @@ import "errors"
 
 var (
 	ErrNoMissingTests = errors.New("no missing tests")
+
+	// ErrExcludedByBuild is returned for the input files, which are not
+	// the part of the selected build variant of the package.
+	ErrExcludedByBuild = errors.New("input file is excluded by the build constraints")
 )
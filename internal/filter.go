@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+)
+
+// fnFilter selects the functions, for which the tests must be generated.
+type fnFilter struct {
+	only     *regexp.Regexp
+	exclude  *regexp.Regexp
+	exported bool
+	receiver string
+}
+
+func newFnFilter(f *Flags) (*fnFilter, error) {
+	var (
+		filter = &fnFilter{exported: f.Exported, receiver: f.Receiver}
+		err    error
+	)
+
+	if f.Only != "" {
+		if filter.only, err = regexp.Compile(f.Only); err != nil {
+			return nil, fmt.Errorf("only: %w", err)
+		}
+	}
+
+	if f.Exclude != "" {
+		if filter.exclude, err = regexp.Compile(f.Exclude); err != nil {
+			return nil, fmt.Errorf("exclude: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// match reports whether the tests must be generated for the function.
+//
+// Regular expressions are matched against the full name of the function,
+// which includes the receiver type for methods, like `Struct.Method`.
+// Functions, which can't be called from the tests, like init, are always skipped.
+func (ff *fnFilter) match(fn *Fn) bool {
+	return callable(fn) && ff.matchReceiver(fn) && ff.matchExported(fn) && ff.matchName(fn.FullName())
+}
+
+func callable(fn *Fn) bool {
+	return fn.Receiver != nil || (fn.Name != "init" && fn.Name != "_")
+}
+
+func (ff *fnFilter) matchReceiver(fn *Fn) bool {
+	return ff.receiver == "" || ff.receiver == fn.structTypeBasedOnReceiver()
+}
+
+// matchExported reports whether the function is exported, methods must be
+// declared on the exported types too.
+func (ff *fnFilter) matchExported(fn *Fn) bool {
+	if !ff.exported {
+		return true
+	}
+
+	return ast.IsExported(fn.Name) && (fn.Receiver == nil || ast.IsExported(fn.structTypeBasedOnReceiver()))
+}
+
+func (ff *fnFilter) matchName(name string) bool {
+	if ff.only != nil && !ff.only.MatchString(name) {
+		return false
+	}
+
+	return ff.exclude == nil || !ff.exclude.MatchString(name)
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fnFilter_match(t *testing.T) {
+	fn := func(name, receiver string) *Fn {
+		f := newFn(name)
+		if receiver != "" {
+			f.Receiver = newIdentifier("r", receiver)
+		}
+
+		return f
+	}
+
+	testcases := []struct {
+		name  string
+		flags *Flags
+		fn    *Fn
+		want  bool
+	}{
+		{
+			name:  "init_is_always_skipped",
+			flags: &Flags{},
+			fn:    fn("init", ""),
+			want:  false,
+		},
+		{
+			name:  "only_matches_full_name",
+			flags: &Flags{Only: `^Service\.`},
+			fn:    fn("Get", "*Service"),
+			want:  true,
+		},
+		{
+			name:  "only_doesnt_match",
+			flags: &Flags{Only: `^Service\.`},
+			fn:    fn("Get", ""),
+			want:  false,
+		},
+		{
+			name:  "excluded",
+			flags: &Flags{Exclude: `Get$`},
+			fn:    fn("Get", "*Service"),
+			want:  false,
+		},
+		{
+			name:  "exported_function",
+			flags: &Flags{Exported: true},
+			fn:    fn("Get", ""),
+			want:  true,
+		},
+		{
+			name:  "exported_method_of_unexported_type",
+			flags: &Flags{Exported: true},
+			fn:    fn("Get", "*service"),
+			want:  false,
+		},
+		{
+			name:  "receiver_with_type_params",
+			flags: &Flags{Receiver: "List"},
+			fn:    fn("Len", "*List[T]"),
+			want:  true,
+		},
+		{
+			name:  "function_with_receiver_filter",
+			flags: &Flags{Receiver: "List"},
+			fn:    fn("Len", ""),
+			want:  false,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newFnFilter(tt.flags)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.want, filter.match(tt.fn))
+		})
+	}
+}
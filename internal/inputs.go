@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// recursiveSuffix is the suffix of the input, which means that all the
+// packages from the directory tree must be processed, like `./...` in go test.
+const recursiveSuffix = "/..."
+
+// IsPackageInput reports whether the input is a package directory or
+// a directory tree pattern, instead of a single file.
+func (f *Flags) IsPackageInput() bool {
+	if f.InputFile == "..." || strings.HasSuffix(f.InputFile, recursiveSuffix) {
+		return true
+	}
+
+	info, err := os.Stat(f.InputFile)
+	return err == nil && info.IsDir()
+}
+
+// InputFiles returns the list of the source files, for which the tests
+// must be generated.
+//
+// The recursive pattern follows the go test semantics: directories, which
+// names begin with "." or "_", testdata and vendor directories, as well as
+// nested modules are skipped.
+func (f *Flags) InputFiles() ([]string, error) {
+	if !f.IsPackageInput() {
+		return []string{f.InputFile}, nil
+	}
+
+	dir, recursive := strings.CutSuffix(f.InputFile, recursiveSuffix)
+	if f.InputFile == "..." {
+		dir, recursive = ".", true
+	}
+
+	if !recursive {
+		return listSourceFiles(dir)
+	}
+
+	var files = make([]string, 0)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if path != dir && skipDir(path) {
+			return filepath.SkipDir
+		}
+
+		dirFiles, err := listSourceFiles(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, dirFiles...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+
+	return files, nil
+}
+
+func skipDir(path string) bool {
+	var name = filepath.Base(path)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+
+	if name == "testdata" || name == "vendor" {
+		return true
+	}
+
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
+
+func listSourceFiles(dir string) ([]string, error) {
+	files, err := listPackageFiles(dir, defaultExcludeFunc(""))
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(files)
+	for i, file := range files {
+		files[i] = filepath.Join(dir, file)
+	}
+
+	return files, nil
+}
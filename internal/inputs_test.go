@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flags_InputFiles(t *testing.T) {
+	var dir = t.TempDir()
+	for _, file := range []string{
+		"a.go",
+		"a_test.go",
+		"README.md",
+		"sub/b.go",
+		"sub/deeper/c.go",
+		"sub/.hidden/d.go",
+		"_skipped/e.go",
+		"testdata/f.go",
+		"vendor/g.go",
+		"nested/go.mod",
+		"nested/h.go",
+	} {
+		var path = filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("package p\n"), 0o600))
+	}
+
+	type want struct {
+		want    []string
+		wantErr require.ErrorAssertionFunc
+	}
+
+	testcases := []struct {
+		name  string
+		input string
+		want  want
+	}{
+		{
+			name:  "single_file",
+			input: filepath.Join(dir, "a.go"),
+			want: want{
+				want:    []string{filepath.Join(dir, "a.go")},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name:  "directory",
+			input: filepath.Join(dir, "sub"),
+			want: want{
+				want:    []string{filepath.Join(dir, "sub", "b.go")},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name:  "recursive",
+			input: dir + "/...",
+			want: want{
+				want: []string{
+					filepath.Join(dir, "a.go"),
+					filepath.Join(dir, "sub", "b.go"),
+					filepath.Join(dir, "sub", "deeper", "c.go"),
+				},
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name:  "missing_directory",
+			input: filepath.Join(dir, "missing") + "/...",
+			want: want{
+				wantErr: require.Error,
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Flags{InputFile: tt.input}
+			got, gotErr := f.InputFiles()
+
+			require.Equal(t, tt.want.want, got)
+			tt.want.wantErr(t, gotErr)
+		})
+	}
+}
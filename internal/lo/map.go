@@ -9,3 +9,14 @@ func MapToSlice[K comparable, V any, R any](in map[K]V, transform func(key K, va
 
 	return out
 }
+
+// MapValues manipulates a map values and transforms it to a map of another type.
+func MapValues[K comparable, V any, R any](in map[K]V, iteratee func(value V, key K) R) map[K]R {
+	out := make(map[K]R, len(in))
+
+	for k := range in {
+		out[k] = iteratee(in[k], k)
+	}
+
+	return out
+}
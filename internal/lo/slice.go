@@ -75,3 +75,34 @@ func SliceToMap[T any, K comparable, V any](collection []T, transform func(item
 
 	return out
 }
+
+// Uniq returns a duplicate-free version of a slice, in which only the first
+// occurrence of each element is kept.
+func Uniq[T comparable](collection []T) []T {
+	out := make([]T, 0, len(collection))
+	seen := make(map[T]struct{}, len(collection))
+
+	for i := range collection {
+		if _, ok := seen[collection[i]]; ok {
+			continue
+		}
+
+		seen[collection[i]] = struct{}{}
+		out = append(out, collection[i])
+	}
+
+	return out
+}
+
+// Filter iterates over elements of collection, returning an array of all elements predicate returns truthy for.
+func Filter[T any](collection []T, predicate func(item T, index int) bool) []T {
+	out := make([]T, 0, len(collection))
+
+	for i := range collection {
+		if predicate(collection[i], i) {
+			out = append(out, collection[i])
+		}
+	}
+
+	return out
+}
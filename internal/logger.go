@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var logFormats = []string{LogFormatText, LogFormatJSON}
+
+// NewLogger creates the logger, which writes the records of the
+// level and format, selected by the flags.
+func NewLogger(w io.Writer, f *Flags) *slog.Logger {
+	var opts = &slog.HandlerOptions{Level: f.logLevel()}
+	if f.LogFormat == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// logLevel returns the level of the logger, verbose mode
+// enables all the records.
+func (f *Flags) logLevel() slog.Level {
+	if f.Verbose {
+		return slog.LevelDebug
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(f.LogLevel)); err != nil {
+		return slog.LevelWarn
+	}
+
+	return level
+}
+
+func parseLogLevel(s string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("unknown log level: %s", s)
+	}
+
+	return nil
+}
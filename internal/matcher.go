@@ -0,0 +1,402 @@
+package internal
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// DefaultTestPatterns are the names of the existing tests, which are
+// recognized as the tests of the function. Patterns with {Type} are used
+// only for the methods, the other ones only for the functions.
+const DefaultTestPatterns = "Test_{Type}_{Name},Test{Type}_{Name},Test{Type}/{Name},Test_{Name},Test{Name}"
+
+const (
+	// ScanOutput looks for the existing tests only in the output file.
+	ScanOutput = "output"
+
+	// ScanPackage looks for the existing tests in all the test files of the package.
+	ScanPackage = "package"
+)
+
+var scanScopes = []string{ScanOutput, ScanPackage}
+
+// kindPrefixes are the prefixes of the names of the generated functions,
+// the patterns of the tests are used for the other kinds with the prefix
+// replaced, like `Benchmark{Name}` for `Test{Name}`.
+var kindPrefixes = map[string]string{
+	GenerateTest:    "Test",
+	GenerateBench:   "Benchmark",
+	GenerateFuzz:    "Fuzz",
+	GenerateExample: "Example",
+}
+
+// testMatcher detects the functions, which are already covered by the
+// existing tests, so the tests are not generated for them again.
+//
+// Names of the tests are compared with the patterns, where `{Type}` is
+// replaced by the receiver type and `{Name}` by the function name. Patterns
+// with `{Type}` are used only for the methods, the other ones only for the
+// functions. Subtests are matched as `Test/sub`. Benchmarks and fuzz targets
+// are matched the same way.
+type testMatcher struct {
+	patterns []string
+
+	// tests are the names of the existing tests and their subtests.
+	tests []string
+
+	// calls are the full names of the functions and methods called by the
+	// tests, nil when the calls detection is disabled.
+	calls map[string]struct{}
+}
+
+func newTestMatcher(f *Flags, files []*ast.File, calls map[string]struct{}) *testMatcher {
+	var patterns = f.TestPatterns
+	if patterns == "" {
+		patterns = DefaultTestPatterns
+	}
+
+	var m = &testMatcher{
+		patterns: lo.FilterMap(strings.Split(patterns, ","), func(p string, _ int) (string, bool) {
+			p = strings.TrimSpace(p)
+			return p, p != ""
+		}),
+		calls: calls,
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+
+			if fn.Recv == nil && hasKindPrefix(fn.Name.Name) {
+				m.tests = append(m.tests, fn.Name.Name)
+				m.tests = append(m.tests, subtests(fn.Name.Name, fn.Body)...)
+			}
+		}
+	}
+
+	return m
+}
+
+// covered returns the name of the existing function of the kind, which
+// covers the function, or the name of the function, when it is called by
+// the tests.
+func (m *testMatcher) covered(fn *Fn, kind string) (string, bool) {
+	var candidates = []string{fn.TestName()}
+	for _, pattern := range m.patterns {
+		if strings.Contains(pattern, "{Type}") != (fn.Receiver != nil) {
+			continue
+		}
+
+		candidates = append(candidates, strings.NewReplacer(
+			"{Type}", fn.structTypeBasedOnReceiver(),
+			"{Name}", fn.Name,
+		).Replace(pattern))
+	}
+
+	for i, candidate := range candidates {
+		candidates[i] = kindPrefixes[kind] + strings.TrimPrefix(candidate, "Test")
+	}
+
+	if kind == GenerateExample {
+		candidates = append(candidates, fn.ExampleName())
+	}
+
+	for _, test := range m.tests {
+		for _, candidate := range candidates {
+			if test == candidate {
+				return test, true
+			}
+		}
+	}
+
+	if _, ok := m.calls[fn.FullName()]; ok && kind == GenerateTest {
+		return fn.FullName(), true
+	}
+
+	return "", false
+}
+
+func hasKindPrefix(name string) bool {
+	for _, prefix := range kindPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subtests returns the names of the subtests, the same way as they are
+// reported by go test. Names are taken from the string literals and from
+// the tables of the testcases, which are declared by the composite literals
+// and ranged over, like `t.Run(tt.name, ...)`.
+func subtests(test string, body *ast.BlockStmt) []string {
+	var names = make([]string, 0)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Run" {
+			return true
+		}
+
+		for _, name := range subtestNames(call.Args[0]) {
+			names = append(names, test+"/"+strings.ReplaceAll(name, " ", "_"))
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// subtestNames returns the values of the name argument of the subtest, which
+// can be found without running the test.
+func subtestNames(arg ast.Expr) []string {
+	switch arg := ast.Unparen(arg).(type) {
+	case *ast.BasicLit:
+		if name, ok := stringLit(arg); ok {
+			return []string{name}
+		}
+	case *ast.SelectorExpr:
+		if ident, ok := arg.X.(*ast.Ident); ok {
+			return tableFieldValues(ident, arg.Sel.Name)
+		}
+	case *ast.Ident:
+		return tableKeys(arg)
+	}
+
+	return nil
+}
+
+// tableFieldValues returns the string values of the field of the testcases,
+// when the variable is the value of the range over the table.
+func tableFieldValues(ident *ast.Ident, field string) []string {
+	table, isKey := rangedTable(ident)
+	if table == nil || isKey {
+		return nil
+	}
+
+	var (
+		index  = fieldIndex(table, field)
+		values = make([]string, 0, len(table.Elts))
+	)
+
+	for _, elt := range table.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+
+		if lit, ok := elt.(*ast.CompositeLit); ok {
+			if value, found := fieldValue(lit, field, index); found {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// tableKeys returns the string keys of the table, when the variable is the
+// key of the range over the table.
+func tableKeys(ident *ast.Ident) []string {
+	table, isKey := rangedTable(ident)
+	if table == nil || !isKey {
+		return nil
+	}
+
+	return lo.FilterMap(table.Elts, func(elt ast.Expr, _ int) (string, bool) {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return "", false
+		}
+
+		return stringLit(kv.Key)
+	})
+}
+
+// rangedTable returns the composite literal, which is ranged over to declare
+// the variable, and whether the variable is the key of the range.
+func rangedTable(ident *ast.Ident) (*ast.CompositeLit, bool) {
+	if ident.Obj == nil {
+		return nil, false
+	}
+
+	// range variables are declared by the assignment of the range expression.
+	assign, ok := ident.Obj.Decl.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return nil, false
+	}
+
+	rng, ok := assign.Rhs[0].(*ast.UnaryExpr)
+	if !ok || rng.Op != token.RANGE {
+		return nil, false
+	}
+
+	key, _ := assign.Lhs[0].(*ast.Ident)
+	return compositeLit(rng.X), key != nil && key.Name == ident.Name
+}
+
+// compositeLit returns the composite literal of the expression, variables
+// are followed to the values they are declared with.
+func compositeLit(expr ast.Expr) *ast.CompositeLit {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.CompositeLit:
+		return expr
+	case *ast.Ident:
+		return compositeLit(declaredValue(expr))
+	default:
+		return nil
+	}
+}
+
+func declaredValue(ident *ast.Ident) ast.Expr {
+	if ident.Obj == nil {
+		return nil
+	}
+
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.AssignStmt:
+		if idx := identIndex(decl.Lhs, ident.Name); idx != -1 && len(decl.Rhs) == len(decl.Lhs) {
+			return decl.Rhs[idx]
+		}
+	case *ast.ValueSpec:
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && i < len(decl.Values) {
+				return decl.Values[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func identIndex(exprs []ast.Expr, name string) int {
+	return slices.IndexFunc(exprs, func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == name
+	})
+}
+
+// fieldIndex returns the index of the field of the struct, which is the
+// element of the table, -1 when the struct is not declared in the file.
+func fieldIndex(table *ast.CompositeLit, field string) int {
+	var elem ast.Expr
+	switch t := table.Type.(type) {
+	case *ast.ArrayType:
+		elem = t.Elt
+	case *ast.MapType:
+		elem = t.Value
+	}
+
+	if ident, ok := elem.(*ast.Ident); ok && ident.Obj != nil {
+		if spec, isType := ident.Obj.Decl.(*ast.TypeSpec); isType {
+			elem = spec.Type
+		}
+	}
+
+	st, ok := elem.(*ast.StructType)
+	if !ok {
+		return -1
+	}
+
+	var names = lo.FlatMap(st.Fields.List, func(f *ast.Field, _ int) []*ast.Ident { return f.Names })
+	return slices.IndexFunc(names, func(name *ast.Ident) bool { return name.Name == field })
+}
+
+// fieldValue returns the string value of the field of the struct literal,
+// fields of the literals without keys are found by the index.
+func fieldValue(lit *ast.CompositeLit, field string, index int) (string, bool) {
+	for i, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			if i == index {
+				return stringLit(elt)
+			}
+
+			continue
+		}
+
+		if key, isIdent := kv.Key.(*ast.Ident); isIdent && key.Name == field {
+			return stringLit(kv.Value)
+		}
+	}
+
+	return "", false
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// collectCalls stores the full names of the functions and methods of the
+// package, which are called inside the node.
+func collectCalls(node ast.Node, info *types.Info, pkg *types.Package, calls map[string]struct{}) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		if fn, isFunc := info.Uses[calleeIdent(call.Fun)].(*types.Func); isFunc && fn.Pkg() == pkg {
+			calls[funcFullName(fn)] = struct{}{}
+		}
+
+		return true
+	})
+}
+
+// calleeIdent returns the identifier of the called function or method,
+// instantiations of the generic functions are skipped.
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch fun := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	case *ast.IndexExpr:
+		return calleeIdent(fun.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(fun.X)
+	default:
+		return nil
+	}
+}
+
+// funcFullName returns the name of the function in the same format as
+// Fn.FullName does, methods are prefixed by the receiver type.
+func funcFullName(fn *types.Func) string {
+	var recv = fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+
+	var t = recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name() + "." + fn.Name()
+	}
+
+	return fn.Name()
+}
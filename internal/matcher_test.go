@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const matcherTests = `package p
+
+import "testing"
+
+func TestParse(t *testing.T) {}
+
+func TestService_Get(t *testing.T) {}
+
+func TestStore(t *testing.T) {
+	t.Run("Put value", func(t *testing.T) {
+		s.Delete()
+	})
+}
+
+type cacheTestcase struct {
+	name string
+	key  string
+}
+
+func TestCache(t *testing.T) {
+	testcases := []struct {
+		key  string
+		name string
+	}{
+		{name: "Get", key: "a"},
+		{"b", "Put value"},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+
+	var named = []cacheTestcase{{"Delete", "c"}}
+	for _, tt := range named {
+		t.Run(tt.key, func(t *testing.T) {})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	for name, tt := range map[string]struct{ size int }{"Push": {size: 1}} {
+		t.Run(name, func(t *testing.T) { _ = tt.size })
+	}
+}
+`
+
+func Test_testMatcher_covered(t *testing.T) {
+	type args struct {
+		flags *Flags
+		calls map[string]struct{}
+		fn    *Fn
+	}
+
+	type want struct {
+		test    string
+		covered bool
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "function_by_default_pattern",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Parse"}},
+			want: want{test: "TestParse", covered: true},
+		},
+		{
+			name: "function_case_sensitive",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "parse"}},
+			want: want{covered: false},
+		},
+		{
+			name: "method_case_sensitive",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "get", Receiver: &Identifier{Type: "*Service"}}},
+			want: want{covered: false},
+		},
+		{
+			name: "method_by_default_pattern",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Get", Receiver: &Identifier{Type: "*Service"}}},
+			want: want{test: "TestService_Get", covered: true},
+		},
+		{
+			name: "method_by_subtest",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Put_value", Receiver: &Identifier{Type: "Store"}}},
+			want: want{test: "TestStore/Put_value", covered: true},
+		},
+		{
+			name: "method_not_matched_by_function_pattern",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Parse", Receiver: &Identifier{Type: "Parser"}}},
+			want: want{covered: false},
+		},
+		{
+			name: "custom_patterns",
+			args: args{flags: &Flags{TestPatterns: "Test_{Name}"}, fn: &Fn{Name: "Parse"}},
+			want: want{covered: false},
+		},
+		{
+			name: "calls_disabled",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "build"}},
+			want: want{covered: false},
+		},
+		{
+			name: "method_by_table_subtest",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Get", Receiver: &Identifier{Type: "*Cache"}}},
+			want: want{test: "TestCache/Get", covered: true},
+		},
+		{
+			name: "method_by_unkeyed_table_subtest",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Put_value", Receiver: &Identifier{Type: "*Cache"}}},
+			want: want{test: "TestCache/Put_value", covered: true},
+		},
+		{
+			name: "method_not_matched_by_other_field",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Delete", Receiver: &Identifier{Type: "*Cache"}}},
+			want: want{covered: false},
+		},
+		{
+			name: "method_by_map_subtest",
+			args: args{flags: &Flags{}, fn: &Fn{Name: "Push", Receiver: &Identifier{Type: "Queue"}}},
+			want: want{test: "TestQueue/Push", covered: true},
+		},
+		{
+			name: "called_function",
+			args: args{flags: &Flags{}, calls: map[string]struct{}{"build": {}}, fn: &Fn{Name: "build"}},
+			want: want{test: "build", covered: true},
+		},
+		{
+			name: "called_method",
+			args: args{
+				flags: &Flags{},
+				calls: map[string]struct{}{"Store.Delete": {}},
+				fn:    &Fn{Name: "Delete", Receiver: &Identifier{Type: "*Store"}},
+			},
+			want: want{test: "Store.Delete", covered: true},
+		},
+		{
+			name: "method_of_other_type_called",
+			args: args{
+				flags: &Flags{},
+				calls: map[string]struct{}{"Store.Delete": {}},
+				fn:    &Fn{Name: "Delete", Receiver: &Identifier{Type: "*Cache"}},
+			},
+			want: want{covered: false},
+		},
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "p_test.go", matcherTests, 0)
+	require.NoError(t, err)
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			test, covered := newTestMatcher(tt.args.flags, []*ast.File{f}, tt.args.calls).covered(tt.args.fn, GenerateTest)
+			require.Equal(t, tt.want.covered, covered)
+			require.Equal(t, tt.want.test, test)
+		})
+	}
+}
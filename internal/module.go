@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// module is a minimal representation of the go.mod file, which is
+// enough to determine the import path of the package and the language
+// features available for the generated code.
+type module struct {
+	Dir  string
+	Path string
+
+	// GoVersion is the version from the go directive, like `1.24`.
+	GoVersion string
+}
+
+// findModule walks up from the directory until it finds the go.mod file.
+// Returns os.ErrNotExist when the directory is not a part of any module.
+func findModule(dir string) (*module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("absolute path: %w", err)
+	}
+
+	for {
+		m, err := parseModFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			m.Dir = dir
+			return m, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, os.ErrNotExist
+		}
+
+		dir = parent
+	}
+}
+
+func parseModFile(path string) (*module, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		m       = new(module)
+		scanner = bufio.NewScanner(f)
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if modPath, ok := strings.CutPrefix(line, "module "); ok {
+			m.Path = unquoteModPath(strings.TrimSpace(modPath))
+		}
+
+		if goVersion, ok := strings.CutPrefix(line, "go "); ok {
+			m.GoVersion = strings.TrimSpace(goVersion)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	if m.Path == "" {
+		return nil, fmt.Errorf("%s: missing module directive", path)
+	}
+
+	return m, nil
+}
+
+func unquoteModPath(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+
+	return s
+}
+
+// importPath returns the import path of the package, stored in the directory.
+func (m *module) importPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("absolute path: %w", err)
+	}
+
+	rel, err := filepath.Rel(m.Dir, dir)
+	if err != nil {
+		return "", fmt.Errorf("relative path: %w", err)
+	}
+
+	if rel == "." {
+		return m.Path, nil
+	}
+
+	return m.Path + "/" + filepath.ToSlash(rel), nil
+}
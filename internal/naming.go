@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// NamingDefault is the naming policy of the generated tests, which is used
+// when the policy is not set.
+const NamingDefault = "Test_Struct_Name"
+
+// namingPresets are the predefined naming policies, which are named after
+// the test of the method `Struct.Name`.
+var namingPresets = map[string]string{
+	NamingDefault:     "Test_{Type}_{Name}",
+	"TestStruct_Name": "Test{Type}_{Name}",
+	"TestName":        "Test{Type}{Name}",
+	"Test_Name":       "Test_{Type}{Name}",
+}
+
+// namingPolicies returns the names of the presets in the stable order.
+func namingPolicies() []string {
+	var policies = lo.MapToSlice(namingPresets, func(name string, _ string) string { return name })
+	slices.Sort(policies)
+	return policies
+}
+
+// namingTemplate returns the template of the test names for the naming
+// policy, which is either a preset or the template itself.
+func namingTemplate(naming string) (string, error) {
+	if naming == "" {
+		naming = NamingDefault
+	}
+
+	if template, ok := namingPresets[naming]; ok {
+		return template, nil
+	}
+
+	if !strings.HasPrefix(naming, "Test") || !strings.Contains(naming, "{Name}") {
+		return "", fmt.Errorf("naming template must start with Test and contain {Name}: %s", naming)
+	}
+
+	return naming, nil
+}
+
+// testName returns the name of the test for the function according to the
+// naming template: `{Type}` is replaced by the receiver type and `{Name}` by
+// the function name. For the functions the `{Type}` is removed together with
+// the following underscore.
+//
+// The first letter after the Test prefix is capitalized, otherwise the
+// test is not recognized by go test.
+func testName(template string, fn *Fn) string {
+	var receiverType = fn.structTypeBasedOnReceiver()
+	if fn.Receiver == nil {
+		template = strings.ReplaceAll(template, "{Type}_", "")
+	}
+
+	var name = strings.NewReplacer("{Type}", receiverType, "{Name}", fn.Name).Replace(template)
+	var rest = strings.TrimPrefix(name, "Test")
+	if r, size := utf8.DecodeRuneInString(rest); unicode.IsLower(r) {
+		return "Test" + string(unicode.ToUpper(r)) + rest[size:]
+	}
+
+	return name
+}
+
+// checkTestNames returns an error, when the naming template gives the same name
+// to the generated functions of the different functions, like `TestClose` to
+// the tests of `A.Close` and `B.Close`, the output can't be compiled then.
+func checkTestNames(fns []*Fn) error {
+	var names = make(map[string]*Fn)
+	for _, fn := range fns {
+		for _, kind := range fn.Kinds {
+			// examples are named after the documented identifier,
+			// so they are unique without the naming template.
+			if kind == GenerateExample {
+				continue
+			}
+
+			var name = kindPrefixes[kind] + strings.TrimPrefix(fn.TestName(), "Test")
+			if other, ok := names[name]; ok {
+				return fmt.Errorf(
+					"naming template gives the same name %s to %s and %s, use {Type} to distinguish them",
+					name, other.FullName(), fn.FullName(),
+				)
+			}
+
+			names[name] = fn
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_testName(t *testing.T) {
+	type args struct {
+		naming string
+		fn     *Fn
+	}
+
+	type want struct {
+		want string
+	}
+
+	var (
+		method   = &Fn{Name: "Get", Receiver: &Identifier{Type: "*Service[T]"}}
+		function = &Fn{Name: "parse"}
+	)
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{name: "default_method", args: args{naming: "", fn: method}, want: want{want: "Test_Service_Get"}},
+		{name: "default_function", args: args{naming: "", fn: function}, want: want{want: "Test_parse"}},
+		{name: "go_style_method", args: args{naming: "TestStruct_Name", fn: method}, want: want{want: "TestService_Get"}},
+		{name: "go_style_function", args: args{naming: "TestStruct_Name", fn: function}, want: want{want: "TestParse"}},
+		{name: "plain_method", args: args{naming: "TestName", fn: method}, want: want{want: "TestServiceGet"}},
+		{name: "underscore_function", args: args{naming: "Test_Name", fn: function}, want: want{want: "Test_parse"}},
+		{
+			name: "template_function",
+			args: args{naming: "Test{Type}_{Name}_Cases", fn: function},
+			want: want{want: "TestParse_Cases"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := namingTemplate(tt.args.naming)
+			require.NoError(t, err)
+			require.Equal(t, tt.want.want, testName(template, tt.args.fn))
+		})
+	}
+}
+
+func Test_checkTestNames(t *testing.T) {
+	type args struct {
+		naming string
+		fns    []*Fn
+	}
+
+	type want struct {
+		wantErr require.ErrorAssertionFunc
+	}
+
+	var (
+		closeA = &Fn{Name: "Close", Receiver: &Identifier{Type: "*A"}, Kinds: []string{GenerateTest}}
+		closeB = &Fn{Name: "Close", Receiver: &Identifier{Type: "B"}, Kinds: []string{GenerateTest, GenerateBench}}
+		benchB = &Fn{Name: "Close", Receiver: &Identifier{Type: "B"}, Kinds: []string{GenerateBench}}
+		funcAB = &Fn{Name: "A_Close", Kinds: []string{GenerateTest}}
+	)
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "default_template",
+			args: args{naming: "", fns: []*Fn{closeA, closeB}},
+			want: want{wantErr: require.NoError},
+		},
+		{
+			name: "template_without_type",
+			args: args{naming: "Test{Name}", fns: []*Fn{closeA, closeB}},
+			want: want{wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.EqualError(t, err, "naming template gives the same name TestClose to A.Close and B.Close, "+
+					"use {Type} to distinguish them")
+			}},
+		},
+		{
+			name: "different_kinds",
+			args: args{naming: "Test{Name}", fns: []*Fn{closeA, benchB}},
+			want: want{wantErr: require.NoError},
+		},
+		{
+			name: "function_named_as_method",
+			args: args{naming: "", fns: []*Fn{closeA, funcAB}},
+			want: want{wantErr: require.Error},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := namingTemplate(tt.args.naming)
+			require.NoError(t, err)
+
+			for _, fn := range tt.args.fns {
+				fn.naming = template
+			}
+
+			tt.want.wantErr(t, checkTestNames(tt.args.fns))
+		})
+	}
+}
@@ -5,12 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/fadyat/ggt/internal/lo"
 )
@@ -24,67 +30,179 @@ import (
 // This struct is responsible for parsing the package and storing the
 // parsed files in the memory, so that we can access them later.
 type PackageParser struct {
-	flags *Flags
+	flags  *Flags
+	logger *slog.Logger
 
 	inputFileSet  *token.FileSet
 	outputFileSet *token.FileSet
 	inputAst      *ast.File
 	outputAst     *ast.File
 
-	// currentPackageFileFileSet will contain the parsed files of the package
-	// which is being processed, caching only the last one, because we are
-	// doing the lazy parsing of the package.
-	currentPackageFileFileSet *token.FileSet
-	currentPackageFileAst     *ast.File
+	// buildContext is used to skip the package files, which are excluded
+	// by the build constraints.
+	buildContext *build.Context
 }
 
-func NewParser(flags *Flags) *PackageParser {
+func NewParser(flags *Flags, logger *slog.Logger) *PackageParser {
 	return &PackageParser{
-		flags: flags,
+		flags:        flags,
+		logger:       logger.With("input", flags.InputFile),
+		buildContext: flags.buildContext(),
 	}
 }
 
 func (p *PackageParser) GenerateMissingTests() (f *File, err error) {
+	if err = p.parseFiles(); err != nil {
+		return nil, err
+	}
+
+	missingTests, err := p.missingTests()
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("found missing tests", "count", len(missingTests))
+
+	if err = p.getStructsForMethods(missingTests); err != nil {
+		return nil, fmt.Errorf("get structs for methods: %w", err)
+	}
+
+	return p.newFile(missingTests)
+}
+
+// parseFiles parses the input file, which matches the build context,
+// and the output file, when it exists.
+func (p *PackageParser) parseFiles() error {
+	match, err := p.buildContext.MatchFile(filepath.Split(p.flags.InputFile))
+	if err != nil {
+		return fmt.Errorf("match build constraints: %w", err)
+	}
+
+	if !match {
+		return ErrExcludedByBuild
+	}
+
 	p.inputFileSet, p.inputAst, err = p.parseFile(p.flags.InputFile)
 	if err != nil {
-		return nil, fmt.Errorf("parse input file: %w", err)
+		return fmt.Errorf("parse input file: %w", err)
 	}
 
 	p.outputFileSet, p.outputAst, err = p.parseFile(p.flags.OutputFile)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("parse output file: %w", err)
+		return fmt.Errorf("parse output file: %w", err)
 	}
 
-	missingTests := p.getMissingTests()
+	return nil
+}
+
+// missingTests returns the functions of the input file selected by the
+// filter, which are not covered by the existing tests.
+func (p *PackageParser) missingTests() ([]*Fn, error) {
+	filter, err := newFnFilter(p.flags)
+	if err != nil {
+		return nil, fmt.Errorf("create filter: %w", err)
+	}
+
+	naming, err := namingTemplate(p.flags.Naming)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := p.testMatcher()
+	if err != nil {
+		return nil, fmt.Errorf("match existing tests: %w", err)
+	}
+
+	missingTests := lo.FilterMap(p.getMissingTests(matcher, naming), func(fn *Fn, _ int) (*Fn, bool) {
+		return fn, filter.match(fn)
+	})
 	if len(missingTests) == 0 {
 		return nil, ErrNoMissingTests
 	}
 
-	if err = p.getStructsForMethods(missingTests); err != nil {
-		return nil, fmt.Errorf("get structs for methods: %w", err)
+	if err = checkTestNames(missingTests); err != nil {
+		return nil, err
 	}
 
+	return missingTests, nil
+}
+
+// newFile returns the file with the missing tests and the details of the
+// input file, which are required for the rendering.
+func (p *PackageParser) newFile(missingTests []*Fn) (*File, error) {
+	// imports of the input file are used as candidates for the generated
+	// tests, when the output file already exists, only the required ones
+	// will be added to it.
 	file := &File{
 		Functions: missingTests,
+		Imports:   lo.Map(p.inputAst.Imports, importToString),
 	}
 
-	if p.outputAst == nil {
-		file.PackageName = p.inputAst.Name.Name
-		file.Imports = lo.Map(p.inputAst.Imports, func(imp *ast.ImportSpec, _ int) string {
-			return imp.Path.Value
-		})
-
-		// appending empty string in cases when no imports exist, but
-		// need to generate imports from the template
-		file.Imports = append(file.Imports, "")
+	var err error
+	file.PackageName = p.inputAst.Name.Name
+	if m, modErr := findModule(filepath.Dir(p.flags.InputFile)); modErr == nil {
+		file.GoVersion = m.GoVersion
+	}
+	if file.BuildConstraint, err = buildConstraint(p.flags.InputFile); err != nil {
+		return nil, fmt.Errorf("read build constraint: %w", err)
+	}
+	if p.flags.typesRequired() {
+		if err = p.loadTypes(file); err != nil {
+			return nil, fmt.Errorf("load types: %w", err)
+		}
 	}
 
 	return file, nil
 }
 
+func (p *PackageParser) loadTypes(file *File) error {
+	loader := NewTypeLoader(filepath.Dir(p.flags.InputFile), p.buildContext)
+	if err := loader.Load(); err != nil {
+		return err
+	}
+
+	if err := loader.Errors(); err != nil {
+		p.logger.Debug("type-checking finished with errors", "error", err)
+	}
+
+	loader.Fill(file)
+	return nil
+}
+
+// buildConstraint returns the `//go:build` line of the file, which is
+// placed before the package clause.
+func buildConstraint(path string) (string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			break
+		}
+
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) {
+				return c.Text, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func importToString(imp *ast.ImportSpec, _ int) string {
+	if imp.Name != nil {
+		return fmt.Sprintf("%s %s", imp.Name.Name, imp.Path.Value)
+	}
+
+	return imp.Path.Value
+}
+
 func (p *PackageParser) parseFile(path string) (*token.FileSet, *ast.File, error) {
 	tokenFileSet := token.NewFileSet()
-	astFile, err := parser.ParseFile(tokenFileSet, path, nil, parser.AllErrors)
+	astFile, err := parser.ParseFile(tokenFileSet, path, nil, parser.AllErrors|parser.ParseComments)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -92,83 +210,201 @@ func (p *PackageParser) parseFile(path string) (*token.FileSet, *ast.File, error
 	return tokenFileSet, astFile, nil
 }
 
-func (p *PackageParser) getMissingTests() []*Fn {
+// getMissingTests returns the functions of the input file, which are not
+// covered by the tests, the names of the tests follow the naming template.
+func (p *PackageParser) getMissingTests(matcher *testMatcher, naming string) []*Fn {
 	inputFuncs := getFuncs(p.inputFileSet, p.inputAst, func(fs *token.FileSet, decl *ast.FuncDecl) *Fn {
 		ff := parseFn(fs, decl)
+		ff.naming = naming
 		ff.generateFriendlyNames(ff.Args)
 		ff.generateFriendlyNames(ff.Results)
 		return ff
 	})
 
-	outputFuncs := getFuncs(p.outputFileSet, p.outputAst, func(fs *token.FileSet, decl *ast.FuncDecl) *Fn {
-		ff := parseFn(fs, decl)
-		return ff
-	})
-
 	return lo.FilterMap(inputFuncs, func(item *Fn, _ int) (*Fn, bool) {
-		return item, !lo.ContainsBy(outputFuncs, func(out *Fn) bool {
-			return item.TestName() == out.Name
-		})
+		for _, kind := range p.flags.Kinds() {
+			if kind == GenerateFuzz && !item.Fuzzable() {
+				p.logger.Debug("function is not fuzzable", "function", item.FullName())
+				continue
+			}
+
+			if kind == GenerateExample && !item.Exemplifiable() {
+				p.logger.Debug("function is not a part of the exported API", "function", item.FullName())
+				continue
+			}
+
+			if test, exists := matcher.covered(item, kind); exists {
+				p.logger.Debug("test already exists", "function", item.FullName(), "kind", kind, "test", test)
+				continue
+			}
+
+			item.Kinds = append(item.Kinds, kind)
+		}
+
+		return item, len(item.Kinds) > 0
 	})
 }
 
-func (p *PackageParser) parseAndMatchStructs(missingStructsFn map[string]*Fn) {
-	fileStructs := lo.SliceToMap(
-		getStructs(p.currentPackageFileFileSet, p.currentPackageFileAst, parseStructs),
-		func(s *Struct) (string, *Struct) { return s.Name, s },
-	)
+// testMatcher returns the matcher of the existing tests, the calls of the
+// tests are loaded only, when they are matched.
+func (p *PackageParser) testMatcher() (*testMatcher, error) {
+	paths, err := p.testFilePaths()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, method := range missingStructsFn {
-		structType := method.structTypeBasedOnReceiver()
-		if s, ok := fileStructs[structType]; ok {
-			method.Struct = s
-			delete(missingStructsFn, method.Name)
+	var files = make([]*ast.File, 0, len(paths))
+	for _, path := range paths {
+		if sameFile(path, p.flags.OutputFile) {
+			files = append(files, p.outputAst)
+			continue
 		}
+
+		p.logger.Debug("parsing test file to find existing tests", "file", path)
+		_, f, parseErr := p.parseFile(path)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse file: %w", parseErr)
+		}
+
+		files = append(files, f)
 	}
+
+	if !p.flags.MatchCalls || len(paths) == 0 {
+		return newTestMatcher(p.flags, files, nil), nil
+	}
+
+	calls, err := NewTypeLoader(filepath.Dir(p.flags.InputFile), p.buildContext).LoadCalls(paths)
+	if err != nil {
+		return nil, fmt.Errorf("load calls: %w", err)
+	}
+
+	return newTestMatcher(p.flags, files, calls), nil
+}
+
+// testFilePaths returns the files, where the existing tests are searched:
+// the output file and, depending on the scan scope, the other test files
+// of the package.
+func (p *PackageParser) testFilePaths() ([]string, error) {
+	var paths = make([]string, 0)
+	if p.outputAst != nil {
+		paths = append(paths, p.flags.OutputFile)
+	}
+
+	if p.flags.Scan != ScanPackage {
+		return paths, nil
+	}
+
+	var dir = filepath.Dir(p.flags.InputFile)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		var path = filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.HasSuffix(path, "_test.go") || sameFile(path, p.flags.OutputFile) {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// sameFile reports whether the paths point to the same file.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
 }
 
 func (p *PackageParser) getStructsForMethods(methods []*Fn) error {
-	missingStructsFn := lo.SliceToMap(
-		lo.FilterMap(methods, func(method *Fn, _ int) (*Fn, bool) {
-			return method, method.Receiver != nil
-		}),
-		func(f *Fn) (string, *Fn) { return f.Name, f },
-	)
+	pending := lo.FilterMap(methods, func(method *Fn, _ int) (*Fn, bool) {
+		return method, method.Receiver != nil
+	})
 
-	if len(missingStructsFn) == 0 {
+	if len(pending) == 0 {
 		return nil
 	}
 
+	var decls = newTypeDecls()
+	decls.add(p.inputFileSet, p.inputAst)
+
+	pending, err := decls.resolve(pending)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	// doing the same logic, but for the rest of the files in the package.
+	packageFiles, excluded, err := p.packageFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range packageFiles {
+		var path = filepath.Join(filepath.Dir(p.flags.InputFile), file)
+		p.logger.Debug("parsing package file to find receiver structs", "file", path)
+		fs, f, parseErr := p.parseFile(path)
+		if parseErr != nil {
+			return fmt.Errorf("parse file: %w", parseErr)
+		}
+
+		decls.add(fs, f)
+		if pending, err = decls.resolve(pending); err != nil || len(pending) == 0 {
+			return err
+		}
+	}
+
+	return p.unresolvedReceivers(decls, pending, excluded)
+}
+
+// packageFiles returns the other files of the package of the input file, the
+// files excluded by the build constraints are not the part of it, so they are
+// returned separately.
+func (p *PackageParser) packageFiles() ([]string, []string, error) {
 	inputFileDir := filepath.Dir(p.flags.InputFile)
 	packageFiles, err := listPackageFiles(inputFileDir, defaultExcludeFunc(p.flags.InputFile))
 	if err != nil {
-		return fmt.Errorf("list package files: %w", err)
+		return nil, nil, fmt.Errorf("list package files: %w", err)
 	}
 
-	p.currentPackageFileFileSet, p.currentPackageFileAst = p.inputFileSet, p.inputAst
+	packageFiles, excluded, err := matchBuildContext(p.buildContext, inputFileDir, packageFiles)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	p.parseAndMatchStructs(missingStructsFn)
-	if len(missingStructsFn) == 0 {
-		return nil
+	for _, path := range excluded {
+		p.logger.Debug("skipping package file excluded by build constraints", "file", path)
 	}
 
-	// doing the same logic, but for the rest of the files in the package
-	for _, file := range packageFiles {
-		p.currentPackageFileFileSet, p.currentPackageFileAst, err = p.parseFile(filepath.Join(inputFileDir, file))
+	return packageFiles, excluded, nil
+}
+
+// unresolvedReceivers returns the error, which explains for each method,
+// why its receiver type wasn't found in the package.
+func (p *PackageParser) unresolvedReceivers(decls *typeDecls, methods []*Fn, excluded []string) error {
+	var excludedTypes = make(map[string]string)
+	for _, path := range excluded {
+		_, f, err := p.parseFile(path)
 		if err != nil {
-			return fmt.Errorf("parse file: %w", err)
+			continue
 		}
 
-		p.parseAndMatchStructs(missingStructsFn)
-		if len(missingStructsFn) == 0 {
-			return nil
+		for _, name := range declaredTypeNames(f) {
+			if _, ok := excludedTypes[name]; !ok {
+				excludedTypes[name] = path
+			}
 		}
 	}
 
-	return fmt.Errorf(
-		"missing structs for the following methods: %s",
-		lo.MapToSlice(missingStructsFn, func(k string, _ *Fn) string { return k }),
-	)
+	var errs = make([]error, 0, len(methods))
+	for _, method := range methods {
+		_, name, _ := decls.lookup(method.structTypeBasedOnReceiver())
+		errs = append(errs, unresolvedReceiverError(method, name, excludedTypes))
+	}
+
+	return errors.Join(errs...)
 }
 
 func getStructs(fs *token.FileSet, f *ast.File, parser func(*token.FileSet, *ast.GenDecl) []*Struct) []*Struct {
@@ -195,8 +431,8 @@ func parseStructs(fs *token.FileSet, decl *ast.GenDecl) []*Struct {
 			continue
 		}
 
-		structType, isStructType := typeSpec.Type.(*ast.StructType)
-		if !isStructType {
+		// aliases are resolved to the aliased types, when matching the receivers.
+		if typeSpec.Assign.IsValid() {
 			continue
 		}
 
@@ -210,6 +446,15 @@ func parseStructs(fs *token.FileSet, decl *ast.GenDecl) []*Struct {
 			})
 		}
 
+		// methods can be declared on any named type, for the non-struct
+		// ones the receiver value is set by the testcase itself.
+		structType, isStructType := typeSpec.Type.(*ast.StructType)
+		if !isStructType {
+			s.Underlying = getTypeName(fs, typeSpec.Type)
+			structs = append(structs, s)
+			continue
+		}
+
 		s.Fields = lo.FlatMap(structType.Fields.List, func(field *ast.Field, _ int) []*Identifier {
 			fieldType := getTypeName(fs, field.Type)
 			if len(field.Names) == 0 {
@@ -245,9 +490,35 @@ func listPackageFiles(path string, exclude func(s string) bool) ([]string, error
 	return result, nil
 }
 
+// matchBuildContext splits the files of the directory into the ones matching
+// the build context and the paths of the excluded ones.
+func matchBuildContext(ctx *build.Context, dir string, files []string) ([]string, []string, error) {
+	var (
+		matched  = make([]string, 0, len(files))
+		excluded = make([]string, 0)
+	)
+
+	for _, file := range files {
+		match, err := ctx.MatchFile(dir, file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("match build constraints: %w", err)
+		}
+
+		if !match {
+			excluded = append(excluded, filepath.Join(dir, file))
+			continue
+		}
+
+		matched = append(matched, file)
+	}
+
+	return matched, excluded, nil
+}
+
 func defaultExcludeFunc(inputFile string) func(string) bool {
+	var inputFileName = filepath.Base(inputFile)
 	return func(s string) bool {
-		if s == inputFile {
+		if s == inputFileName {
 			return true
 		}
 
@@ -280,18 +551,12 @@ func getFuncs(fs *token.FileSet, f *ast.File, parser func(*token.FileSet, *ast.F
 
 func parseFn(fs *token.FileSet, f *ast.FuncDecl) *Fn {
 	var function = newFn(f.Name.Name)
+	function.Panics = documentedPanic(f)
 
 	if f.Recv != nil {
-		var (
-			receiverType = getTypeName(fs, f.Recv.List[0].Type)
-			receiverName = string(receiverType[0]) // todo: mb can be better
-		)
-
-		if len(f.Recv.List[0].Names) > 0 {
-			receiverName = f.Recv.List[0].Names[0].Name
-		}
-
-		function.Receiver = newIdentifier(receiverName, receiverType)
+		var receiverType = getTypeName(fs, f.Recv.List[0].Type)
+		function.Receiver = newIdentifier(receiverName(f.Recv.List[0], receiverType), receiverType)
+		function.Generics = receiverTypeParams(f.Recv.List[0].Type)
 	}
 
 	if f.Type.TypeParams != nil {
@@ -330,6 +595,70 @@ func parseFn(fs *token.FileSet, f *ast.FuncDecl) *Fn {
 	return function
 }
 
+// panicDoc matches the documented form of the panic, like "It panics if"
+// or "Panics when", the preceding word is captured to skip the negations,
+// like "It doesn't panic if".
+var panicDoc = regexp.MustCompile(`(?i)(?:(\S+)\s+)?panics?,?\s+(?:if|when|unless|on|with)\b`)
+
+// panicNegations are the words, which tell that the function doesn't panic.
+var panicNegations = map[string]struct{}{
+	"never": {}, "not": {}, "doesn't": {}, "don't": {}, "won't": {}, "cannot": {}, "can't": {},
+}
+
+// documentedPanic reports whether the function is expected to panic by its
+// doc comment or by the Must prefix followed by the upper case letter or
+// nothing, like regexp.MustCompile.
+func documentedPanic(f *ast.FuncDecl) bool {
+	if rest, ok := strings.CutPrefix(f.Name.Name, "Must"); ok {
+		if r, _ := utf8.DecodeRuneInString(rest); rest == "" || unicode.IsUpper(r) {
+			return true
+		}
+	}
+
+	if f.Doc == nil {
+		return false
+	}
+
+	for _, match := range panicDoc.FindAllStringSubmatch(f.Doc.Text(), -1) {
+		if _, negated := panicNegations[strings.ToLower(match[1])]; !negated {
+			return true
+		}
+	}
+
+	return false
+}
+
+// receiverReservedNames are the names of the variables, which are used by
+// the generated tests and benchmarks, so the receiver can't be named the
+// same way.
+var receiverReservedNames = map[string]struct{}{
+	"_":          {},
+	"t":          {},
+	"tt":         {},
+	"testcases":  {},
+	"b":          {},
+	"benchmarks": {},
+	"ctx":        {},
+}
+
+// receiverName returns the name of the variable, which holds the receiver
+// inside the test, unnamed receivers are named after the first letter of
+// the type.
+func receiverName(recv *ast.Field, receiverType string) string {
+	if len(recv.Names) > 0 {
+		if _, reserved := receiverReservedNames[recv.Names[0].Name]; !reserved {
+			return recv.Names[0].Name
+		}
+	}
+
+	var name = strings.ToLower(string([]rune(strings.TrimLeft(receiverType, "*"))[:1]))
+	if _, reserved := receiverReservedNames[name]; reserved {
+		return "r"
+	}
+
+	return name
+}
+
 func getTypeName(fs *token.FileSet, expr ast.Expr) string {
 	var b bytes.Buffer
 	_ = printer.Fprint(&b, fs, expr)
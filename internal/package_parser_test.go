@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_documentedPanic(t *testing.T) {
+	type args struct {
+		src string
+	}
+
+	type want struct {
+		want bool
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "must_prefix",
+			args: args{src: "func MustParse(s string) int { return 0 }"},
+			want: want{want: true},
+		},
+		{
+			name: "must_only",
+			args: args{src: "func Must(err error) {}"},
+			want: want{want: true},
+		},
+		{
+			name: "must_as_part_of_word",
+			args: args{src: "func Mustache() string { return \"\" }"},
+			want: want{want: false},
+		},
+		{
+			name: "documented_condition",
+			args: args{src: "// Get returns the value.\n// It panics if the key is missing.\nfunc Get(key string) int { return 0 }"},
+			want: want{want: true},
+		},
+		{
+			name: "documented_sentence_start",
+			args: args{src: "// Panics when the index is out of range.\nfunc At(i int) int { return 0 }"},
+			want: want{want: true},
+		},
+		{
+			name: "documented_after_comma",
+			args: args{src: "// Validate panics, when the name is empty.\nfunc Validate(name string) {}"},
+			want: want{want: true},
+		},
+		{
+			name: "never_panics",
+			args: args{src: "// Get returns the value. It never panics.\nfunc Get(key string) int { return 0 }"},
+			want: want{want: false},
+		},
+		{
+			name: "negated_condition",
+			args: args{src: "// Get doesn't panic if the key is missing.\nfunc Get(key string) int { return 0 }"},
+			want: want{want: false},
+		},
+		{
+			name: "panic_mentioned",
+			args: args{src: "// Recover recovers the panic of the handler.\nfunc Recover() {}"},
+			want: want{want: false},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "p.go", "package p\n\n"+tt.args.src, parser.ParseComments)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.want.want, documentedPanic(f.Decls[0].(*ast.FuncDecl)))
+		})
+	}
+}
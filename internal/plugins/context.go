@@ -0,0 +1,98 @@
+package plugins
+
+import (
+	"fmt"
+	"go/version"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// contextField is the name of the testcase field, which returns the
+// context of the testcase.
+const contextField = "ctx"
+
+// contextPlugin removes the context arguments from the args of the testcase,
+// the contexts are passed by the value of the mode instead.
+type contextPlugin struct {
+	mode string
+
+	// contexts are the functions, which have the context arguments.
+	contexts map[*PluggableFn]struct{}
+}
+
+// newContextPlugin returns the plugin for the mode, t.Context() is available
+// since go 1.24, so the older modules get context.Background() instead.
+func newContextPlugin(mode, goVersion string) *contextPlugin {
+	if mode == "" || mode == internal.ContextTest && version.Compare("go"+goVersion, "go1.24") < 0 {
+		mode = internal.ContextBackground
+	}
+
+	return &contextPlugin{mode: mode, contexts: make(map[*PluggableFn]struct{})}
+}
+
+func (p *contextPlugin) Prepare(fn *PluggableFn) {
+	var value string
+	switch p.mode {
+	case internal.ContextTODO:
+		value = "context.TODO()"
+	case internal.ContextTest:
+		value = "t.Context()"
+	case internal.ContextCase:
+		value = contextField
+	default:
+		value = "context.Background()"
+	}
+
+	fn.TestArgs = lo.Filter(fn.TestArgs, func(arg *internal.Identifier, _ int) bool {
+		return !isContext(arg)
+	})
+
+	for i, arg := range fn.Args {
+		if isContext(arg) {
+			fn.CallArgs[i] = value
+			p.contexts[fn] = struct{}{}
+		}
+	}
+}
+
+func (p *contextPlugin) TestcaseFields(fn *PluggableFn) []*internal.Identifier {
+	if _, ok := p.contexts[fn]; !ok || p.mode != internal.ContextCase {
+		return nil
+	}
+
+	return []*internal.Identifier{{Name: contextField, Type: "func(t *testing.T) context.Context"}}
+}
+
+func (p *contextPlugin) Setup(fn *PluggableFn) []string {
+	if _, ok := p.contexts[fn]; !ok || p.mode != internal.ContextCase {
+		return nil
+	}
+
+	return []string{fmt.Sprintf(
+		"%s := context.Background()\nif tt.%s != nil {\n%s = tt.%s(t)\n}",
+		contextField, contextField, contextField, contextField,
+	)}
+}
+
+func (p *contextPlugin) Teardown(*PluggableFn) []string {
+	return nil
+}
+
+// Imports returns the context import, which is not needed only by the
+// t.Context().
+func (p *contextPlugin) Imports() []string {
+	if p.mode == internal.ContextTest {
+		return nil
+	}
+
+	return []string{`"context"`}
+}
+
+// hasContextField reports whether the context of the testcase is set by
+// the testcase field.
+func hasContextField(fn *PluggableFn) bool {
+	return lo.ContainsBy(fn.TestcaseFields, func(field *internal.Identifier) bool {
+		return field.Name == contextField
+	})
+}
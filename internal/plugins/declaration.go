@@ -1,39 +1,266 @@
 package plugins
 
-import "github.com/fadyat/ggt/internal"
+import (
+	"fmt"
+	"go/version"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/lo"
+)
 
 type PluggableFile struct {
-	PackageName string
-	Imports     []string
-	Functions   []*PluggableFn
+	PackageName     string
+	Imports         []string
+	Functions       []*PluggableFn
+	BuildConstraint string
+
+	// Mocks are rendered into the companion file, they are shared
+	// between all the functions of the file.
+	Mocks []*Mock
 }
 
 type PluggableFn struct {
 	*internal.Fn
 
+	// Fields are the struct fields, which are defined inside the testcase.
+	Fields []*internal.Identifier
+
+	// WantFields are the additional fields of the want structure.
+	WantFields []*internal.Identifier
+
+	// TestArgs are the arguments, which are defined inside the testcase.
+	TestArgs []*internal.Identifier
+
+	// CallArgs are the values, which are passed to the function under test,
+	// in the order of the arguments.
+	CallArgs []string
+
+	// StructFields are the values, which are used for the struct creation.
+	StructFields []*StructField
+
+	// Declarations are the types, which are declared inside the test function.
+	Declarations []string
+
+	// TestcaseFields are the additional fields of the testcase structure.
+	TestcaseFields []*internal.Identifier
+
+	// TestcaseValues are the values of the additional fields, like
+	// `prepare: func(m *mocks) {...}`, which are set by the seeded testcases.
+	TestcaseValues []string
+
+	// Setup are the statements, which are executed before the struct creation.
+	Setup []string
+
+	// Teardown are the statements, which are executed when the testcase is
+	// finished, Cleanup is the t.Cleanup call with them, which is rendered
+	// after the Setup.
+	Teardown []string
+	Cleanup  string
+
 	Verification string
+
+	// Testcases are the literals of the seed testcases, an empty
+	// testcase is rendered, when there are no seeds.
+	Testcases []string
+
+	// BenchmarkFields are the struct fields, which are set by the benchmark
+	// cases, they are not changed by the plugins, embedded fields are named
+	// after their types.
+	BenchmarkFields []*internal.Identifier
+
+	// BenchmarkArgs are the arguments, which are defined inside the benchmark
+	// cases, and BenchmarkCallArgs are the values passed to the function
+	// under test by the benchmark. Contexts are passed by the value instead.
+	BenchmarkArgs     []*internal.Identifier
+	BenchmarkCallArgs []string
+
+	// BenchmarkLoop reports whether the benchmarks use the b.Loop, which is
+	// available since go 1.24, instead of the b.N loop.
+	BenchmarkLoop bool
+}
+
+// StructField is a field of the struct, which is set during the struct creation.
+type StructField struct {
+	Name  string
+	Value string
 }
 
-func NewPluggableFile(f *internal.File) *PluggableFile {
-	return &PluggableFile{
-		PackageName: f.PackageName,
-		Imports:     f.Imports,
-		Functions:   newPluggableFns(f.Functions),
+func NewPluggableFile(f *internal.File, flags *internal.Flags, logger *slog.Logger) *PluggableFile {
+	var (
+		backend = newMockBackend(flags.Mocks)
+		mocks   = newMockCollector(f.PackagePath, backend)
+		file    = &PluggableFile{
+			PackageName:     f.PackageName,
+			Imports:         f.Imports,
+			BuildConstraint: f.BuildConstraint,
+		}
+	)
+
+	file.Functions, file.Imports = newPluggableFns(f.Functions, flags, f.GoVersion, mocks, file.Imports, logger)
+	for _, fn := range file.Functions {
+		fn.BenchmarkLoop = version.Compare("go"+f.GoVersion, "go1.24") >= 0
+		fn.BenchmarkArgs, fn.BenchmarkCallArgs = benchmarkArgs(fn.Fn, fn.BenchmarkLoop)
 	}
+
+	// expectations of the testcases return the zero values of the types
+	// used by the mocks, so they need the same imports.
+	file.Mocks = mocks.Mocks()
+	if len(file.Mocks) > 0 {
+		file.Imports = append(file.Imports, backend.Imports()...)
+		file.Imports = lo.Uniq(append(file.Imports, lo.FlatMap(file.Mocks, func(m *Mock, _ int) []string {
+			return m.Imports
+		})...))
+	}
+
+	return file
 }
 
-func newPluggableFns(fns []*internal.Fn) []*PluggableFn {
+// newPluggableFns applies the plugins to the functions, returning them
+// with the imports extended by the ones required by the plugins.
+func newPluggableFns(
+	fns []*internal.Fn,
+	flags *internal.Flags,
+	goVersion string,
+	mocks *mockCollector,
+	imports []string,
+	logger *slog.Logger,
+) ([]*PluggableFn, []string) {
 	var (
 		pluggableFns = make([]*PluggableFn, 0, len(fns))
-		rplugs       = newResultsPlugins()
+		rplugs       = newResultsPlugins(flags.Assert)
+		pplugs       = newPreparePlugins(flags, goVersion, mocks)
 	)
 
+	for _, plugin := range pplugs {
+		if imp, ok := plugin.(importer); ok {
+			imports = append(imports, imp.Imports()...)
+		}
+	}
+
 	for _, fn := range fns {
-		pluggableFns = append(pluggableFns, &PluggableFn{
-			Fn:           fn,
-			Verification: WithResultsPlugins(fn, rplugs),
-		})
+		var (
+			pfn       = newPluggableFn(fn)
+			fnImports []string
+		)
+
+		WithPreparePlugins(pfn, pplugs, logger)
+		pfn.Verification, fnImports = WithResultsPlugins(fn, rplugs, logger)
+		imports = append(imports, fnImports...)
+
+		pfn.Testcases, fnImports = seedTestcases(pfn, flags.Seeds)
+		imports = append(imports, fnImports...)
+		pluggableFns = append(pluggableFns, pfn)
+	}
+
+	return pluggableFns, lo.Uniq(imports)
+}
+
+func newPluggableFn(fn *internal.Fn) *PluggableFn {
+	var pfn = &PluggableFn{
+		Fn:       fn,
+		TestArgs: fn.Args,
+		CallArgs: lo.Map(fn.Args, func(arg *internal.Identifier, _ int) string {
+			return argValue(arg)
+		}),
+	}
+
+	if fn.Struct == nil {
+		return pfn
+	}
+
+	pfn.Fields = fn.Struct.Fields
+	pfn.BenchmarkFields = lo.Map(fn.Struct.Fields, func(field *internal.Identifier, _ int) *internal.Identifier {
+		return &internal.Identifier{Name: fieldName(field), Type: field.Type}
+	})
+	pfn.StructFields = lo.Map(fn.Struct.Fields, func(field *internal.Identifier, _ int) *StructField {
+		var name = fieldName(field)
+		return &StructField{Name: name, Value: fmt.Sprintf("tt.fields.%s", name)}
+	})
+
+	return pfn
+}
+
+// benchmarkArgs returns the arguments of the benchmark cases and the values
+// passed to the function by the benchmark. b.Context() is available since
+// go 1.24 together with the b.Loop, older modules get context.Background().
+func benchmarkArgs(fn *internal.Fn, loop bool) ([]*internal.Identifier, []string) {
+	var value = "context.Background()"
+	if loop {
+		value = "b.Context()"
+	}
+
+	var (
+		args = make([]*internal.Identifier, 0, len(fn.Args))
+		call = make([]string, 0, len(fn.Args))
+	)
+
+	for _, arg := range fn.Args {
+		if isContext(arg) {
+			call = append(call, value)
+			continue
+		}
+
+		args = append(args, arg)
+		call = append(call, argValue(arg))
+	}
+
+	return args, call
+}
+
+// argValue returns the value of the argument, which is set by the case.
+func argValue(arg *internal.Identifier) string {
+	if strings.HasPrefix(arg.Type, "...") {
+		return fmt.Sprintf("tt.args.%s...", arg.Name)
+	}
+
+	return fmt.Sprintf("tt.args.%s", arg.Name)
+}
+
+// snapshot returns the textual representation of the parts of the function,
+// which can be changed by the prepare plugins, to detect the changes.
+func (fn *PluggableFn) snapshot() string {
+	var sb strings.Builder
+	for _, field := range fn.Fields {
+		sb.WriteString(fmt.Sprintf("field %s %s\n", field.Name, field.Type))
+	}
+
+	for _, field := range fn.StructFields {
+		sb.WriteString(fmt.Sprintf("struct %s %s\n", field.Name, field.Value))
+	}
+
+	for _, arg := range fn.TestArgs {
+		sb.WriteString(fmt.Sprintf("arg %s %s\n", arg.Name, arg.Type))
+	}
+
+	sb.WriteString(fmt.Sprintf("call %s\n", strings.Join(fn.CallArgs, ", ")))
+
+	for _, field := range fn.WantFields {
+		sb.WriteString(fmt.Sprintf("want %s %s\n", field.Name, field.Type))
+	}
+
+	for _, field := range fn.TestcaseFields {
+		sb.WriteString(fmt.Sprintf("testcase %s %s\n", field.Name, field.Type))
+	}
+
+	for _, value := range fn.TestcaseValues {
+		sb.WriteString(fmt.Sprintf("value %s\n", value))
+	}
+
+	sb.WriteString(strings.Join(fn.Declarations, "\n"))
+	sb.WriteString(strings.Join(fn.Setup, "\n"))
+	sb.WriteString(strings.Join(fn.Teardown, "\n"))
+	return sb.String()
+}
+
+// pluginName returns the name of the plugin type, which is used in logs.
+func pluginName(plugin any) string {
+	var t = reflect.TypeOf(plugin)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
 
-	return pluggableFns
+	return t.Name()
 }
@@ -0,0 +1,488 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// MockBackend is responsible for the mocks generation with a particular
+// mocking framework. Backend changes only the mocks themselves and the way,
+// how they are created and verified inside the testcase.
+type MockBackend interface {
+
+	// MockName returns the name of the mock type for the interface.
+	MockName(iface *MockInterface) string
+
+	// Generate fills the mock with its source code.
+	Generate(m *Mock, iface *MockInterface)
+
+	// Imports returns the imports required by the testcases, which use mocks.
+	Imports() []string
+
+	// Controller returns the name of the variable with the mocks controller,
+	// empty, when the mocks are created without it.
+	Controller() string
+
+	// Setup returns the statements, which are executed before the mocks creation,
+	// the controller is stored in the variable with the given name.
+	Setup(controller string) []string
+
+	// New returns the expression, which creates the mock with the controller.
+	New(m *Mock, controller string) string
+
+	// Verify returns the statements, which check the expectations of the mock,
+	// stored in the variable, when the testcase is finished.
+	Verify(variable string) []string
+
+	// Expect returns the statement, which sets up the expectation of the method
+	// of the mock, stored in the variable: the method can be called any number
+	// of times and returns the zero values.
+	Expect(variable string, method *MockMethod) string
+}
+
+func newMockBackend(name string) MockBackend {
+	switch name {
+	case internal.MocksGomock:
+		return &gomockBackend{}
+	case internal.MocksTestify:
+		return &testifyBackend{}
+	case internal.MocksMinimock:
+		return &minimockBackend{}
+	default:
+		return &fakeBackend{}
+	}
+}
+
+// fakeBackend generates hand-rolled fakes, where each method of the interface
+// is delegated to the function field with the same signature.
+type fakeBackend struct{}
+
+func (b *fakeBackend) MockName(iface *MockInterface) string {
+	return "mock" + upperFirst(iface.Name)
+}
+
+func (b *fakeBackend) Generate(m *Mock, iface *MockInterface) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// %s is a fake implementation of the %s interface.\n", m.Name, iface.Qualified))
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", m.Name))
+	for _, method := range iface.Methods {
+		sb.WriteString(fmt.Sprintf(
+			"%sFunc func(%s) %s\n",
+			method.Name, defineParams(method.Params), defineResults(method.Results),
+		))
+	}
+	sb.WriteString("}\n")
+
+	for _, method := range iface.Methods {
+		var call = fmt.Sprintf("m.%sFunc(%s)", method.Name, callParams(method.Params, method.Variadic))
+		if len(method.Results) > 0 {
+			call = "return " + call
+		}
+
+		sb.WriteString(fmt.Sprintf(
+			"\nfunc (m *%s) %s(%s) %s {\n%s\n}\n",
+			m.Name, method.Name, defineParams(method.Params), defineResults(method.Results), call,
+		))
+	}
+
+	m.Source = sb.String()
+}
+
+func (b *fakeBackend) Imports() []string { return nil }
+
+func (b *fakeBackend) Controller() string { return "" }
+
+func (b *fakeBackend) Setup(string) []string { return nil }
+
+func (b *fakeBackend) New(m *Mock, _ string) string { return fmt.Sprintf("&%s{}", m.Name) }
+
+func (b *fakeBackend) Verify(string) []string { return nil }
+
+func (b *fakeBackend) Expect(variable string, method *MockMethod) string {
+	return fmt.Sprintf(
+		"%s.%sFunc = func(%s) %s {\n%s}",
+		variable, method.Name, paramTypes(method.Params), defineResults(method.Results), returnZeros(method),
+	)
+}
+
+// testifyBackend generates mocks based on the testify mock.Mock, the
+// expectations are set up with the `On(...)` calls.
+type testifyBackend struct{}
+
+func (b *testifyBackend) MockName(iface *MockInterface) string {
+	return "mock" + upperFirst(iface.Name)
+}
+
+func (b *testifyBackend) Generate(m *Mock, iface *MockInterface) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// %s is a testify mock of the %s interface.\n", m.Name, iface.Qualified))
+	sb.WriteString(fmt.Sprintf("type %s struct {\nmock.Mock\n}\n", m.Name))
+
+	for _, method := range iface.Methods {
+		sb.WriteString(fmt.Sprintf(
+			"\nfunc (m *%s) %s(%s) %s {\n",
+			m.Name, method.Name, defineParams(method.Params), defineResults(method.Results),
+		))
+
+		// variadic arguments are passed as a slice, the same way as mockery does.
+		var call = fmt.Sprintf("m.Called(%s)", callParams(method.Params, false))
+		if len(method.Results) == 0 {
+			sb.WriteString(call + "\n}\n")
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("ret := %s\n", call))
+		writeTypedResults(&sb, method.Results, "ret.Get(%d)")
+		sb.WriteString("}\n")
+	}
+
+	m.Source = sb.String()
+	m.Imports = []string{`"github.com/stretchr/testify/mock"`}
+}
+
+func (b *testifyBackend) Imports() []string { return []string{`"github.com/stretchr/testify/mock"`} }
+
+func (b *testifyBackend) Controller() string { return "" }
+
+func (b *testifyBackend) Setup(string) []string { return nil }
+
+func (b *testifyBackend) New(m *Mock, _ string) string { return fmt.Sprintf("&%s{}", m.Name) }
+
+func (b *testifyBackend) Verify(variable string) []string {
+	return []string{fmt.Sprintf("%s.AssertExpectations(t)", variable)}
+}
+
+func (b *testifyBackend) Expect(variable string, method *MockMethod) string {
+	var args = strconv.Quote(method.Name) + strings.Repeat(", mock.Anything", len(method.Params))
+	return fmt.Sprintf("%s.On(%s)%s.Maybe()", variable, args, returnCall(method))
+}
+
+// gomockBackend generates mocks compatible with the mockgen output, the
+// expectations are set up with the `EXPECT()` calls.
+type gomockBackend struct{}
+
+func (b *gomockBackend) MockName(iface *MockInterface) string {
+	return "Mock" + upperFirst(iface.Name)
+}
+
+func (b *gomockBackend) Generate(m *Mock, iface *MockInterface) {
+	var (
+		sb       strings.Builder
+		recorder = m.Name + "MockRecorder"
+	)
+
+	sb.WriteString(fmt.Sprintf("// %s is a mock of %s interface.\n", m.Name, iface.Qualified))
+	sb.WriteString(fmt.Sprintf(
+		"type %s struct {\nctrl *gomock.Controller\nrecorder *%s\n}\n\n",
+		m.Name, recorder,
+	))
+	sb.WriteString(fmt.Sprintf("// %s is the mock recorder for %s.\n", recorder, m.Name))
+	sb.WriteString(fmt.Sprintf("type %s struct {\nmock *%s\n}\n\n", recorder, m.Name))
+	sb.WriteString(fmt.Sprintf("// New%s creates a new mock instance.\n", m.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func New%s(ctrl *gomock.Controller) *%s {\nmock := &%s{ctrl: ctrl}\nmock.recorder = &%s{mock}\nreturn mock\n}\n\n",
+		m.Name, m.Name, m.Name, recorder,
+	))
+	sb.WriteString("// EXPECT returns an object that allows the caller to indicate expected use.\n")
+	sb.WriteString(fmt.Sprintf("func (m *%s) EXPECT() *%s {\nreturn m.recorder\n}\n", m.Name, recorder))
+
+	for _, method := range iface.Methods {
+		b.writeMethod(&sb, m.Name, method)
+		b.writeRecorder(&sb, m.Name, recorder, method)
+	}
+
+	m.Source = sb.String()
+	m.Imports = []string{`"reflect"`, `"go.uber.org/mock/gomock"`}
+}
+
+func (b *gomockBackend) writeMethod(sb *strings.Builder, mockName string, method *MockMethod) {
+	sb.WriteString(fmt.Sprintf("\n// %s mocks base method.\n", method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func (m *%s) %s(%s) %s {\nm.ctrl.T.Helper()\n",
+		mockName, method.Name, defineParams(method.Params), defineResults(method.Results),
+	))
+
+	var args = callArgs(method.Params, method.Variadic)
+	if method.Variadic {
+		var last = method.Params[len(method.Params)-1].Name
+		sb.WriteString(fmt.Sprintf("varargs := []any{%s}\n", args))
+		sb.WriteString(fmt.Sprintf("for _, arg := range %s {\nvarargs = append(varargs, arg)\n}\n", last))
+		args = "varargs..."
+	}
+
+	var call = fmt.Sprintf("m.ctrl.Call(%s)", strings.Join(nonEmpty("m", fmt.Sprintf("%q", method.Name), args), ", "))
+	if len(method.Results) == 0 {
+		sb.WriteString(call + "\n}\n")
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("ret := %s\n", call))
+	writeTypedResults(sb, method.Results, "ret[%d]")
+	sb.WriteString("}\n")
+}
+
+func (b *gomockBackend) writeRecorder(sb *strings.Builder, mockName, recorder string, method *MockMethod) {
+	var params = make([]string, 0, len(method.Params))
+	for i, p := range method.Params {
+		if method.Variadic && i == len(method.Params)-1 {
+			params = append(params, fmt.Sprintf("%s ...any", p.Name))
+			continue
+		}
+
+		params = append(params, fmt.Sprintf("%s any", p.Name))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n// %s indicates an expected call of %s.\n", method.Name, method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func (mr *%s) %s(%s) *gomock.Call {\nmr.mock.ctrl.T.Helper()\n",
+		recorder, method.Name, strings.Join(params, ", "),
+	))
+
+	var args = callArgs(method.Params, method.Variadic)
+	if method.Variadic {
+		var last = method.Params[len(method.Params)-1].Name
+		sb.WriteString(fmt.Sprintf("varargs := append([]any{%s}, %s...)\n", args, last))
+		args = "varargs..."
+	}
+
+	sb.WriteString(fmt.Sprintf(
+		"return mr.mock.ctrl.RecordCallWithMethodType(%s)\n}\n",
+		strings.Join(nonEmpty(
+			"mr.mock",
+			fmt.Sprintf("%q", method.Name),
+			fmt.Sprintf("reflect.TypeOf((*%s)(nil).%s)", mockName, method.Name),
+			args,
+		), ", "),
+	))
+}
+
+func (b *gomockBackend) Imports() []string { return []string{`"go.uber.org/mock/gomock"`} }
+
+func (b *gomockBackend) Controller() string { return "ctrl" }
+
+func (b *gomockBackend) Setup(controller string) []string {
+	return []string{fmt.Sprintf("%s := gomock.NewController(t)", controller)}
+}
+
+func (b *gomockBackend) New(m *Mock, controller string) string {
+	return fmt.Sprintf("New%s(%s)", m.Name, controller)
+}
+
+func (b *gomockBackend) Verify(string) []string { return nil }
+
+func (b *gomockBackend) Expect(variable string, method *MockMethod) string {
+	var args = strings.TrimSuffix(strings.Repeat("gomock.Any(), ", len(method.Params)), ", ")
+	return fmt.Sprintf("%s.EXPECT().%s(%s)%s.AnyTimes()", variable, method.Name, args, returnCall(method))
+}
+
+// minimockBackend generates mocks with the API of the minimock generator
+// output: the expectations are set up with the `<Method>Mock` fields and
+// checked by the minimock controller. Only the `Set`, `Return` and `Optional`
+// expectations are supported.
+type minimockBackend struct{}
+
+func (b *minimockBackend) MockName(iface *MockInterface) string {
+	return upperFirst(iface.Name) + "Mock"
+}
+
+func (b *minimockBackend) Generate(m *Mock, iface *MockInterface) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// %s is a minimock mock of the %s interface.\n", m.Name, iface.Qualified))
+	sb.WriteString(fmt.Sprintf("type %s struct {\nt minimock.Tester\nfinishOnce sync.Once\n\n", m.Name))
+	for _, method := range iface.Methods {
+		sb.WriteString(fmt.Sprintf("%sMock %s\n", method.Name, minimockExpectation(m, method)))
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("// New%s creates the mock, which expectations are checked on cleanup.\n", m.Name))
+	sb.WriteString(fmt.Sprintf("func New%s(t minimock.Tester) *%s {\nm := &%s{t: t}\n", m.Name, m.Name, m.Name))
+	sb.WriteString("if controller, ok := t.(minimock.MockController); ok {\ncontroller.RegisterMocker(m)\n}\n\n")
+	for _, method := range iface.Methods {
+		sb.WriteString(fmt.Sprintf("m.%sMock = %s{mock: m}\n", method.Name, minimockExpectation(m, method)))
+	}
+	sb.WriteString("t.Cleanup(m.MinimockFinish)\nreturn m\n}\n")
+
+	for _, method := range iface.Methods {
+		b.writeExpectation(&sb, m.Name, method)
+		b.writeMethod(&sb, m.Name, method)
+	}
+
+	b.writeFinish(&sb, m.Name, iface.Methods)
+
+	m.Source = sb.String()
+	m.Imports = []string{`"sync"`, `"sync/atomic"`, `"time"`, `"github.com/gojuno/minimock/v3"`}
+}
+
+// minimockExpectation returns the name of the type, which sets up the
+// expectations of the method, like `mClockMockNow`.
+func minimockExpectation(m *Mock, method *MockMethod) string {
+	return "m" + m.Name + method.Name
+}
+
+func (b *minimockBackend) writeExpectation(sb *strings.Builder, mockName string, method *MockMethod) {
+	var (
+		expectation = "m" + mockName + method.Name
+		funcType    = fmt.Sprintf("func(%s) %s", paramTypes(method.Params), defineResults(method.Results))
+		results     = make([]string, 0, len(method.Results))
+	)
+
+	for i, result := range method.Results {
+		results = append(results, fmt.Sprintf("ret%d %s", i, result))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n// %s sets up the expectations of the %s.%s.\n", expectation, mockName, method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"type %s struct {\nmock *%s\nfunc%s %s\noptional bool\ncalls atomic.Uint64\n}\n",
+		expectation, mockName, method.Name, funcType,
+	))
+
+	sb.WriteString(fmt.Sprintf("\n// Set uses the function as the implementation of the %s.%s.\n", mockName, method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func (mm *%s) Set(f %s) *%s {\nmm.func%s = f\nreturn mm.mock\n}\n",
+		expectation, funcType, mockName, method.Name,
+	))
+
+	sb.WriteString(fmt.Sprintf("\n// Return sets the results of the %s.%s.\n", mockName, method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func (mm *%s) Return(%s) *%s {\nreturn mm.Set(func(%s) %s {\n%s})\n}\n",
+		expectation, strings.Join(results, ", "), mockName,
+		paramTypes(method.Params), defineResults(method.Results), returnValues(resultNames(method)),
+	))
+
+	sb.WriteString(fmt.Sprintf("\n// Optional allows the %s.%s not to be called.\n", mockName, method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func (mm *%s) Optional() *%s {\nmm.optional = true\nreturn mm\n}\n",
+		expectation, expectation,
+	))
+}
+
+func (b *minimockBackend) writeMethod(sb *strings.Builder, mockName string, method *MockMethod) {
+	var expectation = fmt.Sprintf("m.%sMock", method.Name)
+	sb.WriteString(fmt.Sprintf("\n// %s calls the function set up by the expectation.\n", method.Name))
+	sb.WriteString(fmt.Sprintf(
+		"func (m *%s) %s(%s) %s {\nm.t.Helper()\n%s.calls.Add(1)\n",
+		mockName, method.Name, defineParams(method.Params), defineResults(method.Results), expectation,
+	))
+	sb.WriteString(fmt.Sprintf(
+		"if %s.func%s == nil {\nm.t.Fatalf(\"unexpected call to %s.%s\")\n}\n\n",
+		expectation, method.Name, mockName, method.Name,
+	))
+
+	var call = fmt.Sprintf("%s.func%s(%s)", expectation, method.Name, callParams(method.Params, method.Variadic))
+	if len(method.Results) > 0 {
+		call = "return " + call
+	}
+
+	sb.WriteString(call + "\n}\n")
+}
+
+func (b *minimockBackend) writeFinish(sb *strings.Builder, mockName string, methods []*MockMethod) {
+	sb.WriteString("\n// MinimockFinish checks, that all the expected methods were called.\n")
+	sb.WriteString(fmt.Sprintf(
+		"func (m *%s) MinimockFinish() {\nm.finishOnce.Do(func() {\nm.t.Helper()\n"+
+			"for _, method := range m.minimockMissed() {\nm.t.Errorf(\"expected call to %s.%%s\", method)\n}\n})\n}\n",
+		mockName, mockName,
+	))
+
+	sb.WriteString("\n// MinimockWait waits for the expected methods to be called, but not longer than the timeout.\n")
+	sb.WriteString(fmt.Sprintf(
+		"func (m *%s) MinimockWait(timeout time.Duration) {\ndeadline := time.Now().Add(timeout)\n"+
+			"for len(m.minimockMissed()) > 0 && time.Now().Before(deadline) {\ntime.Sleep(time.Millisecond)\n}\n\n"+
+			"m.MinimockFinish()\n}\n",
+		mockName,
+	))
+
+	sb.WriteString("\n// minimockMissed returns the names of the expected methods, which were not called.\n")
+	sb.WriteString(fmt.Sprintf("func (m *%s) minimockMissed() []string {\nvar missed []string\n", mockName))
+	for _, method := range methods {
+		sb.WriteString(fmt.Sprintf(
+			"if m.%sMock.func%s != nil && !m.%sMock.optional && m.%sMock.calls.Load() == 0 {\nmissed = append(missed, %q)\n}\n\n",
+			method.Name, method.Name, method.Name, method.Name, method.Name,
+		))
+	}
+	sb.WriteString("return missed\n}\n")
+}
+
+func (b *minimockBackend) Imports() []string { return []string{`"github.com/gojuno/minimock/v3"`} }
+
+func (b *minimockBackend) Controller() string { return "mc" }
+
+func (b *minimockBackend) Setup(controller string) []string {
+	return []string{fmt.Sprintf("%s := minimock.NewController(t)", controller)}
+}
+
+func (b *minimockBackend) New(m *Mock, controller string) string {
+	return fmt.Sprintf("New%s(%s)", m.Name, controller)
+}
+
+// Verify is not required, the expectations are checked on cleanup.
+func (b *minimockBackend) Verify(string) []string { return nil }
+
+func (b *minimockBackend) Expect(variable string, method *MockMethod) string {
+	return fmt.Sprintf("%s.%sMock.Optional().Return(%s)", variable, method.Name, strings.Join(method.Zeros, ", "))
+}
+
+// returnCall returns the call, which sets the zero results of the method,
+// empty for the methods without results.
+func returnCall(method *MockMethod) string {
+	if len(method.Results) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(".Return(%s)", strings.Join(method.Zeros, ", "))
+}
+
+// returnZeros returns the statement, which returns the zero results of
+// the method, empty for the methods without results.
+func returnZeros(method *MockMethod) string {
+	return returnValues(method.Zeros)
+}
+
+func returnValues(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("return %s\n", strings.Join(values, ", "))
+}
+
+func resultNames(method *MockMethod) []string {
+	return lo.Map(method.Results, func(_ string, i int) string { return fmt.Sprintf("ret%d", i) })
+}
+
+// writeTypedResults writes the type assertions of the mock results, which are
+// accessed by the format with the index, and returns them.
+func writeTypedResults(sb *strings.Builder, results []string, format string) {
+	var names = make([]string, 0, len(results))
+	for i, result := range results {
+		var name = fmt.Sprintf("ret%d", i)
+		sb.WriteString(fmt.Sprintf("%s, _ := %s.(%s)\n", name, fmt.Sprintf(format, i), result))
+		names = append(names, name)
+	}
+
+	sb.WriteString(fmt.Sprintf("return %s\n", strings.Join(names, ", ")))
+}
+
+// callArgs returns the arguments of the method call without the variadic one.
+func callArgs(params []*MockParam, variadic bool) string {
+	if variadic {
+		params = params[:len(params)-1]
+	}
+
+	return callParams(params, false)
+}
+
+func nonEmpty(values ...string) []string {
+	var out = make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
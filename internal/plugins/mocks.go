@@ -0,0 +1,515 @@
+package plugins
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// mockPrepareField is the name of the testcase field, which is used
+// to set up the mocks expectations.
+const mockPrepareField = "prepare"
+
+// Mock is an implementation of the interface, which is generated into
+// the companion file with mocks.
+type Mock struct {
+	// Name is the name of the mock type.
+	Name string
+
+	// Source is the declaration of the mock type and its methods.
+	Source string
+
+	// Imports are required by the Source, in the `[name] "path"` format.
+	Imports []string
+
+	// Methods are the methods of the mocked interface.
+	Methods []*MockMethod
+}
+
+// mockCollector collects the unique mocks for all the functions of
+// the file, because the same interface can be used by multiple structs.
+type mockCollector struct {
+	packagePath string
+	backend     MockBackend
+
+	// mocks are stored by the fully qualified name of the interface.
+	mocks map[string]*Mock
+	names map[string]struct{}
+}
+
+func newMockCollector(packagePath string, backend MockBackend) *mockCollector {
+	return &mockCollector{
+		packagePath: packagePath,
+		backend:     backend,
+		mocks:       make(map[string]*Mock),
+		names:       make(map[string]struct{}),
+	}
+}
+
+// Mocks returns the collected mocks sorted by name.
+func (c *mockCollector) Mocks() []*Mock {
+	var mocks = lo.MapToSlice(c.mocks, func(_ string, m *Mock) *Mock { return m })
+	slices.SortFunc(mocks, func(a, b *Mock) int { return strings.Compare(a.Name, b.Name) })
+	return mocks
+}
+
+// mockable reports whether the identifier can be replaced with a mock:
+// it must be a named non-empty interface, which methods can be implemented
+// outside the package, where the interface is declared.
+func (c *mockCollector) mockable(identifier *internal.Identifier) (*types.Named, bool) {
+	if !identifier.Info.IsInterface() {
+		return nil, false
+	}
+
+	// predeclared interfaces, like error, are not mocked
+	named, ok := types.Unalias(identifier.Info.Type).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil, false
+	}
+
+	iface := named.Underlying().(*types.Interface)
+	for i := range iface.NumMethods() {
+		method := iface.Method(i)
+		if !method.Exported() && method.Pkg() != nil && method.Pkg().Path() != c.packagePath {
+			return nil, false
+		}
+	}
+
+	return named, true
+}
+
+// add returns the mock for the identifier, generating it on the first call.
+func (c *mockCollector) add(identifier *internal.Identifier) (*Mock, bool) {
+	named, ok := c.mockable(identifier)
+	if !ok {
+		return nil, false
+	}
+
+	var key = types.TypeString(named, nil)
+	if m, exists := c.mocks[key]; exists {
+		return m, true
+	}
+
+	var (
+		q     = newQualifier(c.packagePath)
+		iface = newMockInterface(named, c.packagePath, q)
+		m     = &Mock{Name: c.uniqueName(c.backend.MockName(iface)), Methods: iface.Methods}
+	)
+
+	c.backend.Generate(m, iface)
+	m.Imports = lo.Uniq(append(m.Imports, q.imports()...))
+	slices.Sort(m.Imports)
+
+	c.mocks[key] = m
+	return m, true
+}
+
+func (c *mockCollector) uniqueName(name string) string {
+	var unique = name
+	for i := 2; ; i++ {
+		if _, taken := c.names[unique]; !taken {
+			break
+		}
+
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+
+	c.names[unique] = struct{}{}
+	return unique
+}
+
+// MockInterface is the interface, which is replaced with the mock, all the
+// types are printed relative to the package, where the tests are generated.
+type MockInterface struct {
+	// Name is the name of the interface type, like `Writer`.
+	Name string
+
+	// Qualified is the name of the interface inside the tested package,
+	// like `io.Writer`.
+	Qualified string
+
+	// Path is the import path of the package, where the interface is declared.
+	Path string
+
+	Methods []*MockMethod
+}
+
+type MockMethod struct {
+	Name     string
+	Params   []*MockParam
+	Results  []string
+	Variadic bool
+
+	// Zeros are the zero values of the results, which are returned by
+	// the expectations set up in the testcases.
+	Zeros []string
+}
+
+func newMockInterface(named *types.Named, packagePath string, q *qualifier) *MockInterface {
+	// qualified name is used only in comments, so
+	// it doesn't need to be imported.
+	var iface = &MockInterface{
+		Name: named.Obj().Name(),
+		Path: named.Obj().Pkg().Path(),
+		Qualified: types.TypeString(named, func(pkg *types.Package) string {
+			if pkg.Path() == packagePath {
+				return ""
+			}
+
+			return pkg.Name()
+		}),
+	}
+
+	underlying := named.Underlying().(*types.Interface)
+	for i := range underlying.NumMethods() {
+		var (
+			method = underlying.Method(i)
+			sig    = method.Type().(*types.Signature)
+		)
+
+		iface.Methods = append(iface.Methods, &MockMethod{
+			Name:     method.Name(),
+			Params:   signatureParams(sig, q),
+			Results:  signatureResults(sig, q),
+			Variadic: sig.Variadic(),
+			Zeros:    signatureZeros(sig, q),
+		})
+	}
+
+	return iface
+}
+
+// mockReservedNames are the names of the variables, which are used inside
+// the generated mock methods, parameters with such names are renamed.
+var mockReservedNames = map[string]struct{}{
+	"":        {},
+	"_":       {},
+	"m":       {},
+	"mr":      {},
+	"ret":     {},
+	"varargs": {},
+	"arg":     {},
+}
+
+// MockParam is a parameter of the interface method with a name,
+// which is safe to use inside the mock method.
+type MockParam struct {
+	Name string
+	Type string
+}
+
+func signatureParams(sig *types.Signature, q *qualifier) []*MockParam {
+	var params = make([]*MockParam, 0, sig.Params().Len())
+	for i := range sig.Params().Len() {
+		var (
+			v    = sig.Params().At(i)
+			name = v.Name()
+			typ  = types.TypeString(v.Type(), q.qualify)
+		)
+
+		if _, reserved := mockReservedNames[name]; reserved {
+			name = "a" + strconv.Itoa(i)
+		}
+
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			typ = "..." + types.TypeString(v.Type().(*types.Slice).Elem(), q.qualify)
+		}
+
+		params = append(params, &MockParam{Name: name, Type: typ})
+	}
+
+	return params
+}
+
+func signatureResults(sig *types.Signature, q *qualifier) []string {
+	var results = make([]string, 0, sig.Results().Len())
+	for i := range sig.Results().Len() {
+		results = append(results, types.TypeString(sig.Results().At(i).Type(), q.qualify))
+	}
+
+	return results
+}
+
+func signatureZeros(sig *types.Signature, q *qualifier) []string {
+	var zeros = make([]string, 0, sig.Results().Len())
+	for i := range sig.Results().Len() {
+		zeros = append(zeros, zeroValue(sig.Results().At(i).Type(), q))
+	}
+
+	return zeros
+}
+
+// zeroValue returns the expression of the zero value of the type. Basic
+// types are converted explicitly, unless the untyped constant has the same
+// default type, because the mocking frameworks store the results as any.
+func zeroValue(t types.Type, q *qualifier) string {
+	var typ = types.TypeString(t, q.qualify)
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		return fmt.Sprintf("*new(%s)", typ)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return basicZero(t, u, typ)
+	case *types.Struct, *types.Array:
+		return typ + "{}"
+	default:
+		return "nil"
+	}
+}
+
+func basicZero(t types.Type, u *types.Basic, typ string) string {
+	var zero, defaultType = "0", types.Typ[types.Int]
+	switch {
+	case u.Info()&types.IsBoolean != 0:
+		zero, defaultType = "false", types.Typ[types.Bool]
+	case u.Info()&types.IsString != 0:
+		zero, defaultType = `""`, types.Typ[types.String]
+	case u.Kind() == types.UnsafePointer:
+		return "nil"
+	}
+
+	if types.Unalias(t) == defaultType {
+		return zero
+	}
+
+	return fmt.Sprintf("%s(%s)", typ, zero)
+}
+
+func defineParams(params []*MockParam) string {
+	return strings.Join(lo.Map(params, func(p *MockParam, _ int) string {
+		return fmt.Sprintf("%s %s", p.Name, p.Type)
+	}), ", ")
+}
+
+// paramTypes returns the types of the parameters without names, so they
+// don't conflict with the names of the enclosing function.
+func paramTypes(params []*MockParam) string {
+	return strings.Join(lo.Map(params, func(p *MockParam, _ int) string { return p.Type }), ", ")
+}
+
+func callParams(params []*MockParam, variadic bool) string {
+	var names = lo.Map(params, func(p *MockParam, _ int) string { return p.Name })
+	if variadic && len(names) > 0 {
+		names[len(names)-1] += "..."
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func defineResults(results []string) string {
+	switch len(results) {
+	case 0:
+		return ""
+	case 1:
+		return results[0]
+	default:
+		return fmt.Sprintf("(%s)", strings.Join(results, ", "))
+	}
+}
+
+// qualifier is used to print the types relative to the package, where the
+// tests are generated, collecting the imports of the other packages.
+type qualifier struct {
+	packagePath string
+	paths       map[string]string
+}
+
+func newQualifier(packagePath string) *qualifier {
+	return &qualifier{
+		packagePath: packagePath,
+		paths:       make(map[string]string),
+	}
+}
+
+func (q *qualifier) qualify(pkg *types.Package) string {
+	if pkg.Path() == q.packagePath {
+		return ""
+	}
+
+	q.paths[pkg.Path()] = pkg.Name()
+	return pkg.Name()
+}
+
+func (q *qualifier) imports() []string {
+	var imports = lo.MapToSlice(q.paths, func(path, name string) string {
+		if lastElem := path[strings.LastIndex(path, "/")+1:]; lastElem != name {
+			return fmt.Sprintf("%s %q", name, path)
+		}
+
+		return strconv.Quote(path)
+	})
+
+	slices.Sort(imports)
+	return imports
+}
+
+// mockedField is a struct field, which is replaced with the mock.
+type mockedField struct {
+	Name string
+	Mock *Mock
+}
+
+// mocksPlugin replaces the interface fields of the struct with mocks.
+//
+// Mocks are created for each testcase and stored in the `mocks` structure,
+// declared inside the test function, the expectations are set up by the
+// `prepare` function of the testcase.
+type mocksPlugin struct {
+	collector *mockCollector
+	mocked    map[*PluggableFn][]*mockedField
+}
+
+func (p *mocksPlugin) Prepare(fn *PluggableFn) {
+	if fn.Struct == nil {
+		return
+	}
+
+	var mocked = make([]*mockedField, 0)
+	fn.Fields = lo.FilterMap(fn.Fields, func(field *internal.Identifier, _ int) (*internal.Identifier, bool) {
+		m, ok := p.collector.add(field)
+		if !ok {
+			return field, true
+		}
+
+		var name = fieldName(field)
+		mocked = append(mocked, &mockedField{Name: name, Mock: m})
+		for _, sf := range fn.StructFields {
+			if sf.Name == name {
+				sf.Value = fmt.Sprintf("%s.%s", mocksVar(fn), name)
+			}
+		}
+
+		return nil, false
+	})
+
+	if len(mocked) == 0 {
+		return
+	}
+
+	if p.mocked == nil {
+		p.mocked = make(map[*PluggableFn][]*mockedField)
+	}
+
+	p.mocked[fn] = mocked
+	fn.TestcaseValues = append(fn.TestcaseValues, p.expectations(mocked))
+
+	var sb strings.Builder
+	sb.WriteString("type mocks struct {\n")
+	for _, field := range mocked {
+		sb.WriteString(fmt.Sprintf("%s *%s\n", field.Name, field.Mock.Name))
+	}
+	sb.WriteString("}")
+	fn.Declarations = append(fn.Declarations, sb.String())
+}
+
+// expectations returns the value of the prepare field, which allows any calls
+// of the mocked methods, so the calls of the mocks don't fail the seeded testcases.
+func (p *mocksPlugin) expectations(mocked []*mockedField) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: func(m *mocks) {\n", mockPrepareField))
+	for _, field := range mocked {
+		for _, method := range field.Mock.Methods {
+			sb.WriteString(p.collector.backend.Expect("m."+field.Name, method) + "\n")
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (p *mocksPlugin) TestcaseFields(fn *PluggableFn) []*internal.Identifier {
+	if len(p.mocked[fn]) == 0 {
+		return nil
+	}
+
+	return []*internal.Identifier{{Name: mockPrepareField, Type: "func(m *mocks)"}}
+}
+
+func (p *mocksPlugin) Setup(fn *PluggableFn) []string {
+	var mocked = p.mocked[fn]
+	if len(mocked) == 0 {
+		return nil
+	}
+
+	var (
+		sb         strings.Builder
+		backend    = p.collector.backend
+		controller = controllerVar(fn, backend.Controller())
+		setup      = backend.Setup(controller)
+	)
+
+	sb.WriteString(fmt.Sprintf("%s := &mocks{\n", mocksVar(fn)))
+	for _, field := range mocked {
+		sb.WriteString(fmt.Sprintf("%s: %s,\n", field.Name, backend.New(field.Mock, controller)))
+	}
+	sb.WriteString("}")
+	setup = append(setup, sb.String())
+	return append(setup, fmt.Sprintf(
+		"if tt.%s != nil {\ntt.%s(%s)\n}",
+		mockPrepareField, mockPrepareField, mocksVar(fn),
+	))
+}
+
+func (p *mocksPlugin) Teardown(fn *PluggableFn) []string {
+	var teardown = make([]string, 0)
+	for _, field := range p.mocked[fn] {
+		teardown = append(teardown, p.collector.backend.Verify(fmt.Sprintf("%s.%s", mocksVar(fn), field.Name))...)
+	}
+
+	return teardown
+}
+
+// mocksVar returns the name of the variable with mocks, which doesn't
+// conflict with the receiver name.
+func mocksVar(fn *PluggableFn) string {
+	if fn.Receiver != nil && fn.Receiver.Name == "m" {
+		return "ms"
+	}
+
+	return "m"
+}
+
+// controllerVar returns the name of the variable with the mocks controller,
+// which doesn't conflict with the receiver name.
+func controllerVar(fn *PluggableFn, name string) string {
+	if fn.Receiver != nil && fn.Receiver.Name == name {
+		return "mockCtrl"
+	}
+
+	return name
+}
+
+// fieldName returns the name of the struct field, for the embedded fields
+// it is the name of the type without the package and pointer.
+func fieldName(field *internal.Identifier) string {
+	if field.Name != "" {
+		return field.Name
+	}
+
+	var name = strings.TrimPrefix(field.Type, "*")
+	if idx := strings.LastIndex(name, "."); idx != -1 {
+		name = name[idx+1:]
+	}
+
+	if idx := strings.Index(name, "["); idx != -1 {
+		name = name[:idx]
+	}
+
+	return name
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/fadyat/ggt/internal"
+)
+
+// panicField is the name of the want field, which tells whether the
+// function under test is expected to panic.
+const panicField = "wantPanic"
+
+// panicsPlugin expects the panic of the function, the panic is recovered
+// by the deferred function, so the results are not verified after it.
+type panicsPlugin struct {
+	mode   string
+	assert string
+
+	// panics are the functions, which tests expect the panic.
+	panics map[*PluggableFn]struct{}
+}
+
+func newPanicsPlugin(mode, assert string) *panicsPlugin {
+	return &panicsPlugin{mode: mode, assert: assert, panics: make(map[*PluggableFn]struct{})}
+}
+
+func (p *panicsPlugin) Prepare(fn *PluggableFn) {
+	if p.mode != internal.PanicsAll && !fn.Panics {
+		return
+	}
+
+	p.panics[fn] = struct{}{}
+	fn.WantFields = append(fn.WantFields, &internal.Identifier{Name: panicField, Type: "bool"})
+}
+
+func (p *panicsPlugin) TestcaseFields(*PluggableFn) []*internal.Identifier {
+	return nil
+}
+
+func (p *panicsPlugin) Setup(fn *PluggableFn) []string {
+	if _, ok := p.panics[fn]; !ok {
+		return nil
+	}
+
+	switch p.assert {
+	case internal.AssertCmp, internal.AssertStd:
+		return []string{fmt.Sprintf(
+			"defer func() {\nif r := recover(); (r != nil) != tt.want.%s {\nt.Errorf(\"panic = %%v, %s %%v\", r, tt.want.%s)\n}\n}()",
+			panicField, panicField, panicField,
+		)}
+	default:
+		return []string{fmt.Sprintf(
+			"defer func() {\nr := recover()\n%s.Equal(t, tt.want.%s, r != nil, \"panic: %%v\", r)\n}()",
+			p.pkg(), panicField,
+		)}
+	}
+}
+
+func (p *panicsPlugin) Teardown(*PluggableFn) []string {
+	return nil
+}
+
+// Imports returns the imports required by the verification of the panic.
+func (p *panicsPlugin) Imports() []string {
+	switch p.assert {
+	case internal.AssertCmp, internal.AssertStd:
+		return nil
+	default:
+		return []string{testifyImport(p.pkg())}
+	}
+}
+
+func (p *panicsPlugin) pkg() string {
+	if p.assert == internal.AssertAssert {
+		return internal.AssertAssert
+	}
+
+	return internal.AssertRequire
+}
@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fadyat/ggt/internal"
+)
+
+// PreparePlugin is a subset of plugins responsible for the preparation,
+// which is done before the function under test is called.
+type PreparePlugin interface {
+
+	// Prepare patches the function before rendering: it can move the struct
+	// fields out of the testcase, change the values used for the struct
+	// creation and declare the types required by the testcase.
+	Prepare(fn *PluggableFn)
+
+	// TestcaseFields returns the fields, which must be added to the testcase structure.
+	TestcaseFields(fn *PluggableFn) []*internal.Identifier
+
+	// Setup returns the statements, which are executed before the struct creation.
+	Setup(fn *PluggableFn) []string
+
+	// Teardown returns the statements, which are executed by the t.Cleanup,
+	// when the testcase is finished.
+	Teardown(fn *PluggableFn) []string
+}
+
+// importer is implemented by the prepare plugins, which statements
+// require the imports, in the `[name] "path"` format.
+type importer interface {
+	Imports() []string
+}
+
+func WithPreparePlugins(fn *PluggableFn, plugins []PreparePlugin, logger *slog.Logger) {
+	for _, plugin := range plugins {
+		var before = fn.snapshot()
+
+		plugin.Prepare(fn)
+		fn.TestcaseFields = append(fn.TestcaseFields, plugin.TestcaseFields(fn)...)
+		fn.Setup = append(fn.Setup, plugin.Setup(fn)...)
+		fn.Teardown = append(fn.Teardown, plugin.Teardown(fn)...)
+
+		if fn.snapshot() != before {
+			logger.Debug("prepare plugin changed function", "function", fn.FullName(), "plugin", pluginName(plugin))
+		}
+	}
+
+	if len(fn.Teardown) > 0 {
+		fn.Cleanup = fmt.Sprintf("t.Cleanup(func() {\n%s\n})", strings.Join(fn.Teardown, "\n"))
+	}
+}
+
+func newPreparePlugins(flags *internal.Flags, goVersion string, mocks *mockCollector) []PreparePlugin {
+	var plugins = make([]PreparePlugin, 0)
+	if flags.Mocks != "" {
+		plugins = append(plugins, &mocksPlugin{collector: mocks})
+	}
+
+	if flags.Context != internal.ContextNone {
+		plugins = append(plugins, newContextPlugin(flags.Context, goVersion))
+	}
+
+	if flags.Panics != internal.PanicsNone {
+		plugins = append(plugins, newPanicsPlugin(flags.Panics, flags.Assert))
+	}
+
+	return plugins
+}
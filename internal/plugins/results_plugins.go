@@ -2,41 +2,38 @@ package plugins
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/fadyat/ggt/internal"
 	"github.com/fadyat/ggt/internal/lo"
 )
 
-// todo: also need to create the preparation plugins, which accepts the list of arguments
-//  and made some preparation before the test function will be called.
+// Layout of the generated test, `v` marks the parts, which are changed by
+// the plugins: PreparePlugin for the preparation and ResultsPlugin for the results.
 //
-//  - following function need to be added to the testcase structure.
-//  - it accepts the list of arguments (which are determined by the plugin itself)
-//  - it generates the prepare function call with required arguments.
-
 // func test_name {
-//    struct_fields ( interfaces or structs which doesn't require mocks/stubs
-//   				  	+ pluggable to support concrete types for interfaces )		*
-//    func_args		( args are immutable, defined inside the testcase )
+//    struct_fields ( interfaces are replaced with mocks )						v
+//    func_args		( args are defined inside the testcase, except the contexts )	v
 //    func_results	( immutable results + pluggable results ) 						v
 //
 //    testcases := []struct {
 //        name string ( immutable )
 //        fields struct_fields ( immutable )
 //        args func_args ( immutable )
-//        want func_results ( immutable )
+//        want func_results ( immutable + pluggable fields )						v
 //
-//        + pluggable preparation function											*
+//        + pluggable testcase fields, like the prepare function					v
 //    }{
 //        {},
 //    }
 //
 //    for _, tt := range testcases {
 //        t.Run(tt.name, func(t *testing.T) {
-// 		      struct_creation 	( pluggable to support preparation /
-//		   						  different creation techs )	    				*
-// 		      function_call     ( immutable )
+// 		      setup				( pluggable statements before the struct creation )	v
+// 		      teardown			( pluggable statements inside the t.Cleanup )		v
+// 		      struct_creation 	( pluggable values of the struct fields )			v
+// 		      function_call     ( pluggable arguments )							v
 // 		      check_results		( pluggable to support different checks )			v
 //        })
 //    }
@@ -54,35 +51,59 @@ type ResultsPlugin interface {
 	// Map will contain the list of templates, which can be used for particular
 	// result validation.
 	VerifyResults([]*internal.Identifier, map[string][]string)
+
+	// Imports returns the imports required by the validation logic,
+	// in the `[name] "path"` format.
+	Imports() []string
 }
 
-func WithResultsPlugins(fn *internal.Fn, plugins []ResultsPlugin) string {
+// WithResultsPlugins applies the plugins to the function results and returns
+// the validation logic with the imports required by it.
+//
+// Imports are collected only from the plugins, which validation logic is
+// used at the end, because the later plugins can override the earlier ones.
+func WithResultsPlugins(fn *internal.Fn, plugins []ResultsPlugin, logger *slog.Logger) (string, []string) {
 	var (
 		results       = fn.Results
 		verifications = make(map[string][]string)
+		owners        = make(map[string]ResultsPlugin)
 	)
 
 	for _, plugin := range plugins {
+		var before = lo.MapValues(verifications, func(v []string, _ string) string {
+			return strings.Join(v, "\n")
+		})
+
 		plugin.VerifyResults(results, verifications)
+		for name, v := range verifications {
+			if prev, ok := before[name]; !ok || prev != strings.Join(v, "\n") {
+				owners[name] = plugin
+			}
+		}
+
 		results = plugin.PatchResults(results)
 	}
 
 	fn.Results = results
-	return strings.Join(
-		lo.MapToSlice(verifications, func(_ string, v []string) string {
-			return strings.Join(v, "\n")
-		}),
-		"\n",
+
+	var (
+		ordered = make([]string, 0, len(verifications))
+		imports = make([]string, 0)
 	)
-}
 
-// coreDefaultResultsPlugin is a default implementation of the ResultsPlugin interface, which
-// will be used as a base for all other plugins.
-// It doesn't change the results and doesn't provide any additional verification logic.
-type coreDefaultResultsPlugin struct{}
+	// keeping the order of the results to make the output stable
+	for _, result := range results {
+		if v, ok := verifications[result.Name]; ok {
+			ordered = append(ordered, strings.Join(v, "\n"))
+			imports = append(imports, owners[result.Name].Imports()...)
+			logger.Debug(
+				"results plugin verifies result",
+				"function", fn.FullName(), "result", result.Name, "plugin", pluginName(owners[result.Name]),
+			)
+		}
+	}
 
-func (c *coreDefaultResultsPlugin) PatchResults(identifiers []*internal.Identifier) []*internal.Identifier {
-	return identifiers
+	return strings.Join(ordered, "\n"), lo.Uniq(imports)
 }
 
 func toGotSingle(v string) string { // todo: remove me, merge with a renderer
@@ -93,24 +114,92 @@ func toGotSingle(v string) string { // todo: remove me, merge with a renderer
 	return v
 }
 
+// coreDefaultResultsPlugin is a default implementation of the ResultsPlugin interface, which
+// will be used as a base for all other plugins.
+// It doesn't change the results and compares them with the testify Equal function,
+// which is taken from the require or assert package.
+type coreDefaultResultsPlugin struct {
+	pkg string
+}
+
+func (c *coreDefaultResultsPlugin) PatchResults(identifiers []*internal.Identifier) []*internal.Identifier {
+	return identifiers
+}
+
 func (c *coreDefaultResultsPlugin) VerifyResults(identifiers []*internal.Identifier, m map[string][]string) {
 	for _, identifier := range identifiers {
 		m[identifier.Name] = []string{fmt.Sprintf(
-			"require.Equal(t, tt.want.%s, %s)",
+			"%s.Equal(t, tt.want.%s, %s)",
+			c.pkg,
 			identifier.Name,
 			toGotSingle(identifier.Name),
 		)}
 	}
 }
 
+func (c *coreDefaultResultsPlugin) Imports() []string {
+	return []string{testifyImport(c.pkg)}
+}
+
+// cmpResultsPlugin compares the results with the go-cmp, printing the diff
+// between the expected and actual values.
+type cmpResultsPlugin struct{}
+
+func (c *cmpResultsPlugin) PatchResults(identifiers []*internal.Identifier) []*internal.Identifier {
+	return identifiers
+}
+
+func (c *cmpResultsPlugin) VerifyResults(identifiers []*internal.Identifier, m map[string][]string) {
+	for _, identifier := range identifiers {
+		var got = toGotSingle(identifier.Name)
+		m[identifier.Name] = []string{fmt.Sprintf(
+			"if diff := cmp.Diff(tt.want.%s, %s); diff != \"\" {\nt.Errorf(\"%s mismatch (-want +got):\\n%%s\", diff)\n}",
+			identifier.Name,
+			got,
+			got,
+		)}
+	}
+}
+
+func (c *cmpResultsPlugin) Imports() []string {
+	return []string{`"github.com/google/go-cmp/cmp"`}
+}
+
+// stdResultsPlugin compares the results using only the standard library.
+type stdResultsPlugin struct{}
+
+func (s *stdResultsPlugin) PatchResults(identifiers []*internal.Identifier) []*internal.Identifier {
+	return identifiers
+}
+
+func (s *stdResultsPlugin) VerifyResults(identifiers []*internal.Identifier, m map[string][]string) {
+	for _, identifier := range identifiers {
+		var got = toGotSingle(identifier.Name)
+		m[identifier.Name] = []string{fmt.Sprintf(
+			"if !reflect.DeepEqual(%s, tt.want.%s) {\nt.Errorf(\"%s = %%v, want %%v\", %s, tt.want.%s)\n}",
+			got,
+			identifier.Name,
+			got,
+			got,
+			identifier.Name,
+		)}
+	}
+}
+
+func (s *stdResultsPlugin) Imports() []string {
+	return []string{`"reflect"`}
+}
+
 // errorAssertionPlugin is a plugin, which replaces all the error type results with the
 // special assertion function, which called after the function execution.
-type errorAssertionPlugin struct{}
+type errorAssertionPlugin struct {
+	pkg string
+}
 
 func (e *errorAssertionPlugin) PatchResults(identifiers []*internal.Identifier) []*internal.Identifier {
 	for _, identifier := range identifiers {
 		if identifier.Type == "error" {
-			identifier.Type = "require.ErrorAssertionFunc"
+			identifier.Type = fmt.Sprintf("%s.ErrorAssertionFunc", e.pkg)
 		}
 	}
 
@@ -129,9 +218,65 @@ func (e *errorAssertionPlugin) VerifyResults(identifiers []*internal.Identifier,
 	}
 }
 
-func newResultsPlugins() []ResultsPlugin {
-	return []ResultsPlugin{
-		&coreDefaultResultsPlugin{},
-		&errorAssertionPlugin{},
+func (e *errorAssertionPlugin) Imports() []string {
+	return []string{testifyImport(e.pkg)}
+}
+
+// errorFlagPlugin is a plugin for the assertion styles without the error assertion
+// functions, it replaces all the error type results with the flag, which tells
+// whether the error is expected.
+type errorFlagPlugin struct{}
+
+func (e *errorFlagPlugin) PatchResults(identifiers []*internal.Identifier) []*internal.Identifier {
+	for _, identifier := range identifiers {
+		if identifier.Type == "error" {
+			identifier.Type = "bool"
+		}
+	}
+
+	return identifiers
+}
+
+func (e *errorFlagPlugin) VerifyResults(identifiers []*internal.Identifier, m map[string][]string) {
+	for _, identifier := range identifiers {
+		if identifier.Type == "error" {
+			var got = toGotSingle(identifier.Name)
+			m[identifier.Name] = []string{fmt.Sprintf(
+				"if (%s != nil) != tt.want.%s {\nt.Errorf(\"%s = %%v, %s %%v\", %s, tt.want.%s)\n}",
+				got,
+				identifier.Name,
+				got,
+				identifier.Name,
+				got,
+				identifier.Name,
+			)}
+		}
+	}
+}
+
+func (e *errorFlagPlugin) Imports() []string {
+	return nil
+}
+
+func testifyImport(pkg string) string {
+	return fmt.Sprintf("%q", "github.com/stretchr/testify/"+pkg)
+}
+
+func newResultsPlugins(assert string) []ResultsPlugin {
+	switch assert {
+	case internal.AssertCmp:
+		return []ResultsPlugin{&cmpResultsPlugin{}, &errorFlagPlugin{}}
+	case internal.AssertStd:
+		return []ResultsPlugin{&stdResultsPlugin{}, &errorFlagPlugin{}}
+	case internal.AssertAssert:
+		return []ResultsPlugin{
+			&coreDefaultResultsPlugin{pkg: internal.AssertAssert},
+			&errorAssertionPlugin{pkg: internal.AssertAssert},
+		}
+	default:
+		return []ResultsPlugin{
+			&coreDefaultResultsPlugin{pkg: internal.AssertRequire},
+			&errorAssertionPlugin{pkg: internal.AssertRequire},
+		}
 	}
 }
@@ -0,0 +1,257 @@
+package plugins
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// seed is the boundary value of the argument, which is checked by a
+// separate testcase.
+type seed struct {
+	name  string
+	value string
+}
+
+// cancelledContext is the expression of the context, which is already
+// cancelled, when the function is called.
+const cancelledContext = `func() context.Context {
+ctx, cancel := context.WithCancel(context.Background())
+cancel()
+return ctx
+}()`
+
+// cancelledContextFunc is the testcase function, which returns the context
+// already cancelled.
+const cancelledContextFunc = `func(*testing.T) context.Context {
+ctx, cancel := context.WithCancel(context.Background())
+cancel()
+return ctx
+}`
+
+// basicLimits are the boundary values of the basic types, which are
+// declared by the math package.
+var basicLimits = map[string][]*seed{
+	"int":     {{"max_int", "math.MaxInt"}, {"min_int", "math.MinInt"}},
+	"int8":    {{"max_int8", "math.MaxInt8"}, {"min_int8", "math.MinInt8"}},
+	"int16":   {{"max_int16", "math.MaxInt16"}, {"min_int16", "math.MinInt16"}},
+	"int32":   {{"max_int32", "math.MaxInt32"}, {"min_int32", "math.MinInt32"}},
+	"int64":   {{"max_int64", "math.MaxInt64"}, {"min_int64", "math.MinInt64"}},
+	"uint":    {{"max_uint", "math.MaxUint"}},
+	"uint8":   {{"max_uint8", "math.MaxUint8"}},
+	"uint16":  {{"max_uint16", "math.MaxUint16"}},
+	"uint32":  {{"max_uint32", "math.MaxUint32"}},
+	"uint64":  {{"max_uint64", "math.MaxUint64"}},
+	"float32": {{"max_float32", "math.MaxFloat32"}, {"min_float32", "-math.MaxFloat32"}},
+	"float64": {{"max_float64", "math.MaxFloat64"}, {"min_float64", "-math.MaxFloat64"}},
+}
+
+// basicAliases are the predeclared aliases of the basic types.
+var basicAliases = map[string]string{"byte": "uint8", "rune": "int32"}
+
+// seedTestcases returns the testcases, which are seeded by the zero values
+// and the boundary values of the arguments, with the imports required by
+// them. The arguments of the same kind get the cases suffixed by their names.
+// The context set by the testcase field gets the cancelled context case.
+func seedTestcases(fn *PluggableFn, mode string) ([]string, []string) {
+	if mode == internal.SeedsNone {
+		return nil, nil
+	}
+
+	var (
+		argSeeds = make(map[*internal.Identifier][]*seed)
+		counts   = make(map[string]int)
+	)
+
+	if mode != internal.SeedsZero {
+		for _, arg := range fn.TestArgs {
+			argSeeds[arg] = boundarySeeds(fn.Fn, arg)
+			for _, s := range argSeeds[arg] {
+				counts[s.name]++
+			}
+		}
+	}
+
+	var testcases = []string{seedTestcase(fn, "zero_values", nil, "")}
+	if mode != internal.SeedsZero && hasContextField(fn) {
+		testcases = append(testcases, seedTestcase(
+			fn, "cancelled_context", nil, "", fmt.Sprintf("%s: %s", contextField, cancelledContextFunc),
+		))
+	}
+
+	for _, arg := range fn.TestArgs {
+		for _, s := range argSeeds[arg] {
+			var name = s.name
+			if counts[name] > 1 {
+				name = fmt.Sprintf("%s_%s", name, arg.Name)
+			}
+
+			testcases = append(testcases, seedTestcase(fn, name, arg, s.value))
+		}
+	}
+
+	return testcases, []string{`"context"`, `"math"`}
+}
+
+// seedTestcase returns the literal of the testcase, where the argument is
+// set to the value, followed by the extra fields. Contexts are never nil,
+// because it breaks the contract of the most functions. Error assertions
+// are set to NoError, the expected results are left to the user.
+func seedTestcase(fn *PluggableFn, name string, arg *internal.Identifier, value string, extra ...string) string {
+	var values = make([]string, 0)
+	for _, a := range fn.TestArgs {
+		switch {
+		case a == arg:
+			values = append(values, fmt.Sprintf("%s: %s", a.Name, value))
+		case isContext(a):
+			values = append(values, fmt.Sprintf("%s: context.Background()", a.Name))
+		}
+	}
+
+	var fields = []string{fmt.Sprintf("name: %q", name)}
+	if len(values) > 0 {
+		fields = append(fields, fmt.Sprintf("args: %s{%s}", instantiated(fn, "args"), strings.Join(values, ", ")))
+	}
+
+	if wants := errorAssertionWants(fn); len(wants) > 0 {
+		fields = append(fields, fmt.Sprintf("want: %s{%s}", instantiated(fn, "want"), strings.Join(wants, ", ")))
+	}
+
+	fields = append(fields, fn.TestcaseValues...)
+	fields = append(fields, extra...)
+	if len(fields) == 1 {
+		return fmt.Sprintf("{%s}", fields[0])
+	}
+
+	return fmt.Sprintf("{\n%s,\n}", strings.Join(fields, ",\n"))
+}
+
+// instantiated returns the type of the testcase struct, instantiated by any
+// for the generic functions, the same way as the testcase fields are.
+func instantiated(fn *PluggableFn, typ string) string {
+	if len(fn.Generics) == 0 {
+		return typ
+	}
+
+	return typ + "[" + strings.Repeat("any, ", len(fn.Generics)-1) + "any]"
+}
+
+// errorAssertionWants returns the want values of the error assertion
+// functions, which expect no error.
+func errorAssertionWants(fn *PluggableFn) []string {
+	return lo.FilterMap(fn.Results, func(result *internal.Identifier, _ int) (string, bool) {
+		pkg, ok := strings.CutSuffix(result.Type, ".ErrorAssertionFunc")
+		return fmt.Sprintf("%s: %s.NoError", result.Name, pkg), ok
+	})
+}
+
+// boundarySeeds returns the boundary values of the argument, which are
+// based on the resolved type, when the type-aware parsing is enabled,
+// and on the type expression otherwise. Slices and pointers are seeded
+// both by nil and by the empty non-nil values, maps only by the empty
+// ones, because nil maps can't be written.
+func boundarySeeds(fn *internal.Fn, arg *internal.Identifier) []*seed {
+	if isContext(arg) {
+		return []*seed{{"cancelled_context", cancelledContext}}
+	}
+
+	var typ = instantiateGenerics(fn, arg.Type)
+	if elem, ok := strings.CutPrefix(typ, "..."); ok {
+		typ = "[]" + elem
+	}
+
+	switch kind := seedKind(fn, arg); kind {
+	case seedSlice:
+		return []*seed{{"nil_slice", "nil"}, {"empty_slice", typ + "{}"}}
+	case seedMap:
+		return []*seed{{"empty_map", typ + "{}"}}
+	case seedPointer:
+		// pointers of the named types can't be created by new,
+		// because the type of the pointed value is unknown.
+		if elem, ok := strings.CutPrefix(typ, "*"); ok {
+			return []*seed{{"nil_pointer", "nil"}, {"pointer_to_zero", fmt.Sprintf("new(%s)", elem)}}
+		}
+
+		return []*seed{{"nil_pointer", "nil"}}
+	case "string":
+		return []*seed{{"empty_string", `""`}}
+	default:
+		return basicLimits[kind]
+	}
+}
+
+const (
+	seedSlice   = "slice"
+	seedMap     = "map"
+	seedPointer = "pointer"
+)
+
+// seedKind returns the kind of the argument type: slice, map, pointer or
+// the name of the basic type.
+func seedKind(fn *internal.Fn, arg *internal.Identifier) string {
+	if arg.Info != nil && arg.Info.Type != nil {
+		return typeSeedKind(arg.Info.Type)
+	}
+
+	var typ = arg.Type
+	switch {
+	case lo.ContainsBy(fn.Generics, func(g *internal.Identifier) bool { return g.Name == typ }):
+		return ""
+	case strings.HasPrefix(typ, "..."), strings.HasPrefix(typ, "[]"):
+		return seedSlice
+	case strings.HasPrefix(typ, "map["):
+		return seedMap
+	case strings.HasPrefix(typ, "*"):
+		return seedPointer
+	}
+
+	if alias, ok := basicAliases[typ]; ok {
+		return alias
+	}
+
+	return typ
+}
+
+func typeSeedKind(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		return seedSlice
+	case *types.Map:
+		return seedMap
+	case *types.Pointer:
+		return seedPointer
+	case *types.Basic:
+		if alias, ok := basicAliases[u.Name()]; ok {
+			return alias
+		}
+
+		return u.Name()
+	default:
+		return ""
+	}
+}
+
+// instantiateGenerics replaces the type parameters of the function in the
+// type expression by any, the same way as the testcase fields are instantiated.
+func instantiateGenerics(fn *internal.Fn, typ string) string {
+	for _, g := range fn.Generics {
+		var re = regexp.MustCompile(`(^|[^.\w])` + regexp.QuoteMeta(g.Name) + `\b`)
+		typ = re.ReplaceAllString(typ, "${1}any")
+	}
+
+	return typ
+}
+
+func isContext(arg *internal.Identifier) bool {
+	if arg.Info != nil && arg.Info.Type != nil {
+		named, ok := arg.Info.Type.(*types.Named)
+		return ok && named.Obj().Pkg() != nil &&
+			named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+	}
+
+	return arg.Type == "context.Context"
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// typeDecls are the named types declared in the already parsed files of the
+// package, they are collected file by file to resolve the method receivers.
+type typeDecls struct {
+	structs map[string]*Struct
+
+	// aliases maps the alias name to the aliased type expression,
+	// like `Z` for `type X = Z`.
+	aliases map[string]string
+}
+
+func newTypeDecls() *typeDecls {
+	return &typeDecls{
+		structs: make(map[string]*Struct),
+		aliases: make(map[string]string),
+	}
+}
+
+func (d *typeDecls) add(fs *token.FileSet, f *ast.File) {
+	for _, s := range getStructs(fs, f, parseStructs) {
+		d.structs[s.Name] = s
+	}
+
+	for name, target := range parseAliases(fs, f) {
+		d.aliases[name] = target
+	}
+}
+
+// lookup returns the type declaration with the given name, following the
+// aliases. The returned name is the last one in the alias chain, it is
+// returned with a nil struct, when the type is not declared yet.
+func (d *typeDecls) lookup(name string) (*Struct, string, error) {
+	var seen = make(map[string]struct{})
+	for {
+		if s, ok := d.structs[name]; ok {
+			return s, name, nil
+		}
+
+		target, ok := d.aliases[name]
+		if !ok {
+			return nil, name, nil
+		}
+
+		if _, ok = seen[name]; ok {
+			return nil, name, fmt.Errorf("alias %s refers to itself", name)
+		}
+
+		// methods can be declared only on the types of the package, so only
+		// the aliases of the local named types are allowed as receivers.
+		if !token.IsIdentifier(target) {
+			return nil, name, fmt.Errorf(
+				"%s is an alias of %s, which is not a named type declared in the package", name, target,
+			)
+		}
+
+		seen[name] = struct{}{}
+		name = target
+	}
+}
+
+// resolve sets the receiver types of the methods, which are declared in the
+// collected files, the methods with the types not found yet are returned.
+func (d *typeDecls) resolve(methods []*Fn) ([]*Fn, error) {
+	var pending = make([]*Fn, 0, len(methods))
+	for _, method := range methods {
+		s, _, err := d.lookup(method.structTypeBasedOnReceiver())
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", method.FullName(), err)
+		}
+
+		if s == nil {
+			pending = append(pending, method)
+			continue
+		}
+
+		method.setStruct(s)
+	}
+
+	return pending, nil
+}
+
+func parseAliases(fs *token.FileSet, f *ast.File) map[string]string {
+	var aliases = make(map[string]string)
+	if f == nil {
+		return aliases
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			typeSpec, isTypeSpec := spec.(*ast.TypeSpec)
+			if isTypeSpec && typeSpec.Assign.IsValid() {
+				aliases[typeSpec.Name.Name] = getTypeName(fs, typeSpec.Type)
+			}
+		}
+	}
+
+	return aliases
+}
+
+// receiverTypeParams returns the type parameters of the generic receiver,
+// like `T` for `*List[T]`, their constraints are known only after the
+// receiver type is resolved.
+func receiverTypeParams(expr ast.Expr) []*Identifier {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			return typeParamIdentifiers([]ast.Expr{e.Index})
+		case *ast.IndexListExpr:
+			return typeParamIdentifiers(e.Indices)
+		default:
+			return nil
+		}
+	}
+}
+
+func typeParamIdentifiers(exprs []ast.Expr) []*Identifier {
+	var params = make([]*Identifier, 0, len(exprs))
+	for _, expr := range exprs {
+		if ident, ok := expr.(*ast.Ident); ok {
+			params = append(params, newIdentifier(ident.Name, ""))
+		}
+	}
+
+	return params
+}
+
+// unresolvedReceiverError describes, why the receiver type of the method
+// wasn't found in the package.
+func unresolvedReceiverError(method *Fn, name string, excluded map[string]string) error {
+	var reason = fmt.Sprintf("receiver type %s is not declared in the package", name)
+	if receiver := method.structTypeBasedOnReceiver(); receiver != name {
+		reason = fmt.Sprintf("receiver type %s is an alias of %s, which is not declared in the package", receiver, name)
+	}
+
+	if file, ok := excluded[name]; ok {
+		reason = fmt.Sprintf(
+			"receiver type %s is declared only in %s, which is excluded by the build constraints", name, file,
+		)
+	}
+
+	return fmt.Errorf("method %s: %s", method.FullName(), reason)
+}
+
+// declaredTypeNames returns the names of the types declared in the file.
+func declaredTypeNames(f *ast.File) []string {
+	var names = make([]string, 0)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			if typeSpec, isTypeSpec := spec.(*ast.TypeSpec); isTypeSpec {
+				names = append(names, typeSpec.Name.Name)
+			}
+		}
+	}
+
+	return names
+}
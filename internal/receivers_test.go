@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+func Test_PackageParser_getStructsForMethods(t *testing.T) {
+	type args struct {
+		input string
+		files map[string]string
+	}
+
+	type want struct {
+		structs []string
+		err     string
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "generic_receiver_in_another_file",
+			args: args{
+				input: "package p\n\nfunc (l *List[T]) Len() int { return 0 }\n",
+				files: map[string]string{"list.go": "package p\n\ntype List[E any] struct{ items []E }\n"},
+			},
+			want: want{structs: []string{"List"}},
+		},
+		{
+			name: "alias_declared_before_type",
+			args: args{
+				input: "package p\n\ntype X = y\n\nfunc (x X) Do() {}\n",
+				files: map[string]string{"y.go": "package p\n\ntype y struct{}\n"},
+			},
+			want: want{structs: []string{"y"}},
+		},
+		{
+			name: "same_method_name_on_different_types",
+			args: args{
+				input: "package p\n\ntype a struct{}\n\ntype b struct{}\n\nfunc (a) Do() {}\n\nfunc (b) Do() {}\n",
+			},
+			want: want{structs: []string{"a", "b"}},
+		},
+		{
+			name: "not_declared",
+			args: args{input: "package p\n\nfunc (x *X) Do() {}\n"},
+			want: want{err: "method X.Do: receiver type X is not declared in the package"},
+		},
+		{
+			name: "excluded_by_build_constraints",
+			args: args{
+				input: "package p\n\nfunc (x *X) Do() {}\n",
+				files: map[string]string{"x.go": "//go:build ignore\n\npackage p\n\ntype X struct{}\n"},
+			},
+			want: want{err: "method X.Do: receiver type X is declared only in {dir}/x.go, " +
+				"which is excluded by the build constraints"},
+		},
+		{
+			name: "alias_of_another_package",
+			args: args{input: "package p\n\nimport \"time\"\n\ntype X = time.Time\n\nfunc (x X) Do() {}\n"},
+			want: want{err: "method X.Do: X is an alias of time.Time, which is not a named type declared in the package"},
+		},
+		{
+			name: "alias_of_missing_type",
+			args: args{input: "package p\n\ntype X = y\n\nfunc (x X) Do() {}\n"},
+			want: want{err: "method X.Do: receiver type X is an alias of y, which is not declared in the package"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			var dir = t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "p.go"), []byte(tt.args.input), 0o600))
+			for name, content := range tt.args.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+			}
+
+			parser := NewParser(&Flags{
+				InputFile:  filepath.Join(dir, "p.go"),
+				OutputFile: filepath.Join(dir, "p_test.go"),
+			}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			file, err := parser.GenerateMissingTests()
+			if tt.want.err != "" {
+				require.ErrorContains(t, err, strings.ReplaceAll(tt.want.err, "{dir}", dir))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want.structs, lo.Map(file.Functions, func(fn *Fn, _ int) string {
+				return fn.Struct.Name
+			}))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package renderer
+
+import (
+	"errors"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/plugins"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// goldenDir contains the cases of the golden tests: each case is a package,
+// all its files are used as inputs, the options are set by the .ggt.yaml.
+// Expected outputs are stored next to the inputs with the .golden suffix.
+const goldenDir = "testdata/golden"
+
+// stubsDir contains the stubs of the packages, which are imported by the
+// generated tests, but are not the dependencies of the module, like gomock.
+// Stubs are stored by their import paths.
+const stubsDir = "testdata/stubs"
+
+func Test_Golden(t *testing.T) {
+	cases, err := os.ReadDir(goldenDir)
+	require.NoError(t, err)
+
+	var (
+		fs  = token.NewFileSet()
+		imp = newStubImporter(fs, importer.ForCompiler(fs, "source", nil))
+	)
+
+	for _, c := range cases {
+		if !c.IsDir() {
+			continue
+		}
+
+		t.Run(c.Name(), func(t *testing.T) {
+			var (
+				dir             = filepath.Join(goldenDir, c.Name())
+				outDir          = t.TempDir()
+				outputs, inputs = generateGolden(t, dir, outDir)
+			)
+
+			for name, content := range outputs {
+				var golden = filepath.Join(dir, name+".golden")
+				if *update {
+					require.NoError(t, os.WriteFile(golden, content, 0o644))
+					continue
+				}
+
+				expected, readErr := os.ReadFile(golden)
+				require.NoError(t, readErr, "run the tests with -update to create the golden file")
+				require.Equal(t, string(expected), string(content), "output %s differs from the golden file", name)
+			}
+
+			goldens, globErr := filepath.Glob(filepath.Join(dir, "*.golden"))
+			require.NoError(t, globErr)
+			for _, golden := range goldens {
+				var name = strings.TrimSuffix(filepath.Base(golden), ".golden")
+				if _, ok := outputs[name]; !ok {
+					if *update {
+						require.NoError(t, os.Remove(golden))
+						continue
+					}
+
+					require.Failf(t, "golden file is not generated", "%s", golden)
+				}
+			}
+
+			typeCheckGolden(t, fs, imp, dir, inputs, outputs)
+		})
+	}
+}
+
+// generateGolden runs the generation for all the inputs of the case, the
+// outputs are stored in the outDir, so the next inputs are appended to them.
+// The inputs, which are the part of the selected build variant, are returned
+// together with the outputs. Test files of the case are the existing tests,
+// they are not used as the inputs of the generation.
+func generateGolden(t *testing.T, dir, outDir string) (map[string][]byte, []string) {
+	inputs, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	require.NoError(t, err)
+
+	var (
+		logger  = slog.New(slog.NewTextHandler(io.Discard, nil))
+		outputs = make(map[string][]byte)
+		matched = make([]string, 0, len(inputs))
+	)
+
+	for _, input := range inputs {
+		if strings.HasSuffix(input, "_test.go") {
+			matched = append(matched, input)
+			continue
+		}
+
+		var base = strings.TrimSuffix(filepath.Base(input), ".go")
+		f, forFileErr := (&internal.Flags{Assert: internal.AssertRequire, Version: "golden"}).ForFile(input)
+		require.NoError(t, forFileErr)
+		f.OutputFile = filepath.Join(outDir, base+"_test.go")
+
+		file, parseErr := internal.NewParser(f, logger).GenerateMissingTests()
+		if errors.Is(parseErr, internal.ErrExcludedByBuild) {
+			continue
+		}
+
+		matched = append(matched, input)
+		if errors.Is(parseErr, internal.ErrNoMissingTests) {
+			continue
+		}
+
+		require.NoError(t, parseErr)
+
+		rendered, renderErr := NewRenderer(f, logger).Render(plugins.NewPluggableFile(file, f, logger))
+		require.NoError(t, renderErr)
+		require.NoError(t, Write(rendered))
+
+		for _, out := range rendered {
+			outputs[filepath.Base(out.Path)] = out.Content
+		}
+	}
+
+	return outputs, matched
+}
+
+// typeCheckGolden checks, that the generated tests are compiled together
+// with the inputs of the case.
+func typeCheckGolden(
+	t *testing.T, fs *token.FileSet, imp types.Importer, dir string, inputs []string, outputs map[string][]byte,
+) {
+	var files = make([]*ast.File, 0, len(inputs)+len(outputs))
+	for _, input := range inputs {
+		f, parseErr := parser.ParseFile(fs, input, nil, 0)
+		require.NoError(t, parseErr)
+		files = append(files, f)
+	}
+
+	for name, content := range outputs {
+		f, parseErr := parser.ParseFile(fs, filepath.Join(dir, name), content, 0)
+		require.NoError(t, parseErr)
+		files = append(files, f)
+	}
+
+	var conf = types.Config{Importer: imp}
+	_, err := conf.Check(files[0].Name.Name, fs, files, nil)
+	require.NoError(t, err, "generated tests don't type-check")
+}
+
+// stubImporter imports the packages stored in the stubsDir from their source,
+// the other ones are imported by the fallback importer.
+type stubImporter struct {
+	fs       *token.FileSet
+	fallback types.Importer
+	pkgs     map[string]*types.Package
+}
+
+func newStubImporter(fs *token.FileSet, fallback types.Importer) *stubImporter {
+	return &stubImporter{fs: fs, fallback: fallback, pkgs: make(map[string]*types.Package)}
+}
+
+func (i *stubImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.pkgs[path]; ok {
+		return pkg, nil
+	}
+
+	sources, err := filepath.Glob(filepath.Join(stubsDir, filepath.FromSlash(path), "*.go"))
+	if err != nil || len(sources) == 0 {
+		return i.fallback.Import(path)
+	}
+
+	var files = make([]*ast.File, 0, len(sources))
+	for _, source := range sources {
+		f, parseErr := parser.ParseFile(i.fs, source, nil, 0)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		files = append(files, f)
+	}
+
+	var conf = types.Config{Importer: i}
+	pkg, err := conf.Check(path, i.fs, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	i.pkgs[path] = pkg
+	return pkg, nil
+}
@@ -0,0 +1,761 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fadyat/ggt/internal/lo"
+)
+
+// defaultImports are the imports, which are always used by the rendered
+// testcases, no matter which functions are being tested.
+var defaultImports = []string{
+	`"testing"`,
+}
+
+// importSpec is a parsed representation of the import string, which is
+// stored inside the internal.File in the `[name] "path"` format.
+type importSpec struct {
+	Name string
+	Path string
+}
+
+func parseImportSpec(s string) (*importSpec, bool) {
+	var (
+		fields = strings.Fields(s)
+		spec   = new(importSpec)
+		err    error
+	)
+
+	switch len(fields) {
+	case 1:
+		spec.Path, err = strconv.Unquote(fields[0])
+	case 2:
+		spec.Name = fields[0]
+		spec.Path, err = strconv.Unquote(fields[1])
+	default:
+		return nil, false
+	}
+
+	return spec, err == nil
+}
+
+// localName returns the name, which is used to access the package
+// inside the file.
+//
+// For imports without explicit name the name is assumed from the path in the
+// same way as goimports does: major version suffixes, like `/v2`, and `go-`
+// prefix are skipped, the name is cut at the first non-identifier character.
+// It is not always correct, because package name can differ from the directory
+// name, but it is good enough without loading the package itself.
+func (s *importSpec) localName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	var base = path.Base(s.Path)
+	if isMajorVersion(base) {
+		base = path.Base(path.Dir(s.Path))
+	}
+
+	base = strings.TrimPrefix(base, "go-")
+	if idx := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); idx != -1 {
+		base = base[:idx]
+	}
+
+	return base
+}
+
+func (s *importSpec) String() string {
+	if s.Name != "" {
+		return s.Name + " " + strconv.Quote(s.Path)
+	}
+
+	return strconv.Quote(s.Path)
+}
+
+// isStdlib reports whether the path belongs to the standard library, the same
+// way as goimports does: the first element of the path has no dots.
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// unresolvedPackages returns the names of the identifiers, which are used as
+// a package selectors inside the file, but are not declared in it.
+func unresolvedPackages(f *ast.File) map[string]struct{} {
+	var (
+		scopes = declaredScopes(f)
+		used   = make(map[string]struct{})
+	)
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		var declared = slices.ContainsFunc(scopes[ident.Name], func(s scope) bool {
+			return s.pos <= ident.Pos() && ident.Pos() < s.end
+		})
+
+		if !declared {
+			used[ident.Name] = struct{}{}
+		}
+
+		return true
+	})
+
+	return used
+}
+
+// scope is the part of the file, where the declared name is visible.
+type scope struct {
+	pos, end token.Pos
+}
+
+// declarations are the scopes of the declared names.
+type declarations map[string][]scope
+
+func (d declarations) declare(owner ast.Node, idents ...*ast.Ident) {
+	for _, ident := range idents {
+		if ident != nil && ident.Name != "_" {
+			d[ident.Name] = append(d[ident.Name], scope{pos: ident.Pos(), end: owner.End()})
+		}
+	}
+}
+
+func (d declarations) declareFields(owner ast.Node, lists ...*ast.FieldList) {
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+
+		for _, field := range list.List {
+			d.declare(owner, field.Names...)
+		}
+	}
+}
+
+func (d declarations) declareExprs(owner ast.Node, exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		ident, _ := expr.(*ast.Ident)
+		d.declare(owner, ident)
+	}
+}
+
+// declaredScopes returns the scopes of the names declared inside the file,
+// each name is visible from its declaration till the end of the innermost
+// block, statement or function enclosing it.
+//
+// Shadowing is not tracked, the name being declared anywhere in the enclosing
+// scopes is enough to tell it is not a package.
+func declaredScopes(f *ast.File) declarations {
+	var (
+		decls = make(declarations)
+		stack = make([]ast.Node, 0)
+	)
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		stack = append(stack, n)
+		decls.visit(n, innermostScope(stack))
+		return true
+	})
+
+	return decls
+}
+
+// visit declares the names introduced by the node, owner is the innermost
+// scope enclosing it.
+func (d declarations) visit(n, owner ast.Node) {
+	switch node := n.(type) {
+	case *ast.FuncDecl:
+		if node.Recv == nil {
+			d.declare(owner, node.Name)
+		}
+
+		d.declareFields(node, node.Recv, node.Type.TypeParams, node.Type.Params, node.Type.Results)
+	case *ast.FuncLit:
+		d.declareFields(node, node.Type.Params, node.Type.Results)
+	case *ast.AssignStmt:
+		if node.Tok == token.DEFINE {
+			d.declareExprs(owner, node.Lhs...)
+		}
+	case *ast.RangeStmt:
+		if node.Tok == token.DEFINE {
+			d.declareExprs(node, node.Key, node.Value)
+		}
+	case *ast.ValueSpec:
+		d.declare(owner, node.Names...)
+	case *ast.TypeSpec:
+		d.declare(owner, node.Name)
+	}
+}
+
+// innermostScope returns the closest node of the stack, which limits the
+// visibility of the names declared inside it.
+func innermostScope(stack []ast.Node) ast.Node {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.File, *ast.BlockStmt, *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt,
+			*ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.CaseClause, *ast.CommClause:
+			return stack[i]
+		}
+	}
+
+	return stack[0]
+}
+
+// stdlibImports are the packages of the standard library, which are
+// commonly used by the tests. They are added when referenced, even if
+// no plugin asked for them.
+var stdlibImports = []string{
+	`"bytes"`,
+	`"context"`,
+	`"errors"`,
+	`"fmt"`,
+	`"io"`,
+	`"os"`,
+	`"reflect"`,
+	`"strings"`,
+	`"sync"`,
+	`"testing"`,
+	`"time"`,
+}
+
+// neededImports returns the subset of candidates, which are referenced by the
+// file, but not yet imported by the imports.
+func neededImports(f *ast.File, imports []*ast.ImportSpec, candidates []string) []*importSpec {
+	var (
+		used     = unresolvedPackages(f)
+		imported = make(map[string]struct{}, len(imports))
+		result   = make([]*importSpec, 0)
+	)
+
+	for _, imp := range imports {
+		spec := specOf(imp)
+		imported[spec.Path] = struct{}{}
+		delete(used, spec.localName())
+	}
+
+	for _, candidate := range candidates {
+		spec, ok := parseImportSpec(candidate)
+		if !ok {
+			continue
+		}
+
+		if _, ok = imported[spec.Path]; ok {
+			continue
+		}
+
+		if _, ok = used[spec.localName()]; !ok {
+			continue
+		}
+
+		imported[spec.Path] = struct{}{}
+		delete(used, spec.localName())
+		result = append(result, spec)
+	}
+
+	return result
+}
+
+func specOf(imp *ast.ImportSpec) *importSpec {
+	var spec = new(importSpec)
+	spec.Path, _ = strconv.Unquote(imp.Path.Value)
+	if imp.Name != nil {
+		spec.Name = imp.Name.Name
+	}
+
+	return spec
+}
+
+// removeOwnImports removes the imports, which are not protected, and returns
+// them, so the used ones are added back in the right groups. Blank and dot
+// imports are always kept, because their usage can't be detected.
+func removeOwnImports(f *ast.File, protected map[string]struct{}) []string {
+	var own = make([]string, 0)
+	f.Imports = lo.Filter(f.Imports, func(imp *ast.ImportSpec, _ int) bool {
+		var spec = specOf(imp)
+		if _, ok := protected[spec.Path]; ok || spec.Name == "_" || spec.Name == "." {
+			return true
+		}
+
+		own = append(own, spec.String())
+		return false
+	})
+
+	var kept = make(map[*ast.ImportSpec]struct{}, len(f.Imports))
+	for _, imp := range f.Imports {
+		kept[imp] = struct{}{}
+	}
+
+	f.Decls = lo.Filter(f.Decls, func(d ast.Decl, _ int) bool {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			return true
+		}
+
+		var specs = lo.Filter(gen.Specs, func(s ast.Spec, _ int) bool {
+			_, isKept := kept[s.(*ast.ImportSpec)]
+			return isKept
+		})
+
+		// positions are collected before the specs are moved, because the
+		// kept specs are the part of the original ones.
+		var positions = lo.Map(gen.Specs, func(s ast.Spec, _ int) token.Pos { return s.Pos() })
+
+		// moving the kept specs to the positions of the first ones, otherwise
+		// the printer treats the gaps of the removed lines as the group separators.
+		for i, spec := range specs {
+			var (
+				imp = spec.(*ast.ImportSpec)
+				pos = positions[i]
+			)
+
+			if imp.Name != nil {
+				imp.Name.NamePos = pos
+			}
+
+			imp.Path.ValuePos = pos
+			imp.EndPos = 0
+		}
+
+		gen.Specs = specs
+		return len(gen.Specs) > 0
+	})
+
+	return own
+}
+
+// addImports adds the import specs to the first import declaration of the
+// source, creating it if needed. Imports of the standard library are added
+// to the first group with them and the others to the first group without
+// them, new groups are created after the standard ones, as gci does.
+//
+// Source is edited as the text, because the printer can't separate the specs
+// added to the AST by the empty lines.
+func addImports(src []byte, specs []*importSpec) ([]byte, error) {
+	if len(specs) == 0 {
+		return src, nil
+	}
+
+	var fs = token.NewFileSet()
+	f, err := parser.ParseFile(fs, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, withSourceLine(fmt.Errorf("parse: %w", err), src)
+	}
+
+	var std, other []*importSpec
+	for _, spec := range sortedSpecs(specs) {
+		if isStdlib(spec.Path) {
+			std = append(std, spec)
+		} else {
+			other = append(other, spec)
+		}
+	}
+
+	var (
+		tf   = fs.File(f.Pos())
+		decl = firstImportDecl(f)
+	)
+
+	switch {
+	case decl == nil:
+		var end = lineEnd(src, tf.Offset(f.Name.End()))
+		return insert(src, insertion{offset: end, text: "\n\n" + importDecl(specLines(std), specLines(other))}), nil
+	case !decl.Lparen.IsValid():
+		return replaceImportDecl(src, tf, decl, std, other), nil
+	case len(decl.Specs) == 0:
+		return insert(src, insertion{offset: tf.Offset(decl.Lparen) + 1, text: "\n" + importGroupsText(specLines(std), specLines(other))}), nil
+	default:
+		return insert(src, groupInsertions(importGroups(src, tf, decl), std, other)...), nil
+	}
+}
+
+func firstImportDecl(f *ast.File) *ast.GenDecl {
+	for _, d := range f.Decls {
+		if gen, ok := d.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			return gen
+		}
+	}
+
+	return nil
+}
+
+// replaceImportDecl replaces the declaration of the single import without
+// parens by the grouped declaration with the new specs, the line of the
+// existing import is moved as is.
+func replaceImportDecl(src []byte, tf *token.File, decl *ast.GenDecl, std, other []*importSpec) []byte {
+	var (
+		imp      = decl.Specs[0].(*ast.ImportSpec)
+		start    = tf.Offset(decl.Pos())
+		end      = lineEnd(src, tf.Offset(decl.End()))
+		line     = strings.TrimSpace(string(src[tf.Offset(imp.Pos()):end]))
+		path     = specOf(imp).Path
+		stdLines = specLines(std)
+		others   = specLines(other)
+	)
+
+	if isStdlib(path) {
+		stdLines = slices.Insert(stdLines, specIndex(std, path), line)
+	} else {
+		others = slices.Insert(others, specIndex(other, path), line)
+	}
+
+	var result = make([]byte, 0, len(src))
+	result = append(result, src[:start]...)
+	result = append(result, importDecl(stdLines, others)...)
+	return append(result, src[end:]...)
+}
+
+// sortedSpecs returns the specs in the order of gofmt: by path and then by name.
+func sortedSpecs(specs []*importSpec) []*importSpec {
+	var sorted = slices.Clone(specs)
+	slices.SortStableFunc(sorted, func(a, b *importSpec) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return sorted
+}
+
+// specIndex returns the index of the first sorted spec with the greater path.
+func specIndex(specs []*importSpec, path string) int {
+	if idx := slices.IndexFunc(specs, func(s *importSpec) bool { return s.Path > path }); idx != -1 {
+		return idx
+	}
+
+	return len(specs)
+}
+
+func specLines(specs []*importSpec) []string {
+	return lo.Map(specs, func(s *importSpec, _ int) string { return s.String() })
+}
+
+// importGroup is the part of the import declaration, which is separated
+// from the other specs by the empty lines.
+type importGroup struct {
+	// start is the offset of the first line of the group,
+	// end is the offset of the end of its last line.
+	start, end int
+
+	// std and other report whether the group has the imports of the standard
+	// library and the other ones, blank and dot imports are not counted.
+	std, other bool
+
+	// paths and starts are the paths of the specs and the offsets of their
+	// first lines, including the doc comments.
+	paths  []string
+	starts []int
+}
+
+func importGroups(src []byte, tf *token.File, decl *ast.GenDecl) []*importGroup {
+	var (
+		groups   []*importGroup
+		lastLine int
+	)
+
+	for _, s := range decl.Specs {
+		var (
+			imp   = s.(*ast.ImportSpec)
+			start = imp.Pos()
+		)
+
+		if imp.Doc != nil {
+			start = imp.Doc.Pos()
+		}
+
+		if len(groups) == 0 || tf.Line(start) > lastLine+1 {
+			groups = append(groups, &importGroup{start: lineStart(src, tf.Offset(start))})
+		}
+
+		var group = groups[len(groups)-1]
+		group.end = lineEnd(src, tf.Offset(imp.End()))
+		group.paths = append(group.paths, specOf(imp).Path)
+		group.starts = append(group.starts, lineStart(src, tf.Offset(start)))
+		lastLine = tf.Line(imp.End())
+
+		if spec := specOf(imp); spec.Name != "_" && spec.Name != "." {
+			group.std = group.std || isStdlib(spec.Path)
+			group.other = group.other || !isStdlib(spec.Path)
+		}
+	}
+
+	return groups
+}
+
+// groupInsertions returns the insertions of the specs into the groups,
+// the other imports are returned first, so they follow the standard ones
+// being inserted at the same offset.
+func groupInsertions(groups []*importGroup, std, other []*importSpec) []insertion {
+	var insertions = make([]insertion, 0, 2)
+	if len(other) > 0 {
+		if idx := slices.IndexFunc(groups, func(g *importGroup) bool { return g.other }); idx != -1 {
+			insertions = append(insertions, groups[idx].insertions(other)...)
+		} else {
+			insertions = append(insertions, insertion{offset: groups[len(groups)-1].end, text: "\n\n" + importLines(specLines(other))})
+		}
+	}
+
+	if len(std) > 0 {
+		if idx := slices.IndexFunc(groups, func(g *importGroup) bool { return g.std }); idx != -1 {
+			insertions = append(insertions, groups[idx].insertions(std)...)
+		} else {
+			insertions = append(insertions, insertion{offset: groups[0].start, text: importLines(specLines(std)) + "\n\n"})
+		}
+	}
+
+	return insertions
+}
+
+// insertions returns the insertions of the sorted specs into the group, each
+// spec is placed before the first spec with the greater path, so the sorted
+// group stays sorted.
+func (g *importGroup) insertions(specs []*importSpec) []insertion {
+	var (
+		lines   = make(map[int][]string)
+		offsets = make([]int, 0, len(specs))
+	)
+
+	for _, spec := range specs {
+		var offset = g.end
+		if idx := slices.IndexFunc(g.paths, func(p string) bool { return p > spec.Path }); idx != -1 {
+			offset = g.starts[idx]
+		}
+
+		if _, ok := lines[offset]; !ok {
+			offsets = append(offsets, offset)
+		}
+
+		lines[offset] = append(lines[offset], spec.String())
+	}
+
+	return lo.Map(offsets, func(offset int, _ int) insertion {
+		if offset == g.end {
+			return insertion{offset: offset, text: "\n" + importLines(lines[offset])}
+		}
+
+		return insertion{offset: offset, text: importLines(lines[offset]) + "\n"}
+	})
+}
+
+// importDecl returns the declaration of the specs, parens are omitted
+// for the single spec, as gofmt does.
+func importDecl(std, other []string) string {
+	if specs := slices.Concat(std, other); len(specs) == 1 {
+		return "import " + specs[0]
+	}
+
+	return "import (\n" + importGroupsText(std, other) + "\n)"
+}
+
+// importGroupsText returns the specs of the standard library and the other
+// ones as the separate groups, specs are sorted later by the formatting.
+func importGroupsText(std, other []string) string {
+	var groups = make([]string, 0, 2)
+	for _, lines := range [][]string{std, other} {
+		if len(lines) > 0 {
+			groups = append(groups, importLines(lines))
+		}
+	}
+
+	return strings.Join(groups, "\n\n")
+}
+
+func importLines(lines []string) string {
+	return "\t" + strings.Join(lines, "\n\t")
+}
+
+type insertion struct {
+	offset int
+	text   string
+}
+
+// insert applies the insertions from the end of the source, so the offsets
+// of the rest stay valid. Insertions at the same offset are placed in the
+// reverse order.
+func insert(src []byte, insertions ...insertion) []byte {
+	slices.SortStableFunc(insertions, func(a, b insertion) int { return b.offset - a.offset })
+	for _, ins := range insertions {
+		src = slices.Insert(slices.Clip(src), ins.offset, []byte(ins.text)...)
+	}
+
+	return src
+}
+
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+func lineEnd(src []byte, offset int) int {
+	if idx := bytes.IndexByte(src[offset:], '\n'); idx != -1 {
+		return offset + idx
+	}
+
+	return len(src)
+}
+
+// fixImports adds the imports from the candidates and the standard library,
+// which are referenced by the source, and removes the unused ones, except the
+// protected, the same way as goimports does. Result is formatted.
+//
+// Imports, which are not protected, are added back only when used, so they
+// are grouped the same way as the new ones.
+func fixImports(src []byte, protected map[string]struct{}, candidates []string) ([]byte, error) {
+	var fs = token.NewFileSet()
+	f, err := parser.ParseFile(fs, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, withSourceLine(fmt.Errorf("parse: %w", err), src)
+	}
+
+	var (
+		own    = removeOwnImports(f, protected)
+		needed = neededImports(f, f.Imports, append(append(own, candidates...), stdlibImports...))
+		b      bytes.Buffer
+	)
+
+	if err = format.Node(&b, fs, f); err != nil {
+		return nil, fmt.Errorf("print: %w", err)
+	}
+
+	grouped, err := addImports(b.Bytes(), needed)
+	if err != nil {
+		return nil, err
+	}
+
+	// printing the modified AST can leave the positions of the comments
+	// inconsistent, so formatting the printed source once again.
+	content, err := format.Source(grouped)
+	if err != nil {
+		return nil, withSourceLine(fmt.Errorf("format: %w", err), b.Bytes())
+	}
+
+	return content, nil
+}
+
+// mergeImports appends the generated declarations to the existing file source,
+// adding the imports from candidates, which are required by the new declarations.
+// The existing file is not reprinted, new imports are spliced into its import
+// declaration, so the rest of it stays byte-for-byte the same.
+func mergeImports(existing, generated []byte, candidates []string) ([]byte, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", existing, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("parse existing file: %w", err)
+	}
+
+	decls, err := formatDecls(generated)
+	if err != nil {
+		return nil, err
+	}
+
+	gen, err := parser.ParseFile(token.NewFileSet(), "", decls, 0)
+	if err != nil {
+		return nil, withSourceLine(fmt.Errorf("parse: %w", err), decls)
+	}
+
+	content, err := addImports(existing, neededImports(gen, f.Imports, slices.Concat(candidates, stdlibImports)))
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+
+	content = append(content, '\n')
+	return append(content, bytes.TrimLeft(bytes.TrimPrefix(decls, []byte(declsPackage)), "\n")...), nil
+}
+
+// declsPackage is the package clause, which makes the generated declarations
+// a valid file for the formatting, it is cut from the formatted source.
+const declsPackage = "package p\n"
+
+// formatDecls formats the generated declarations as a separate file,
+// the result starts with the declsPackage clause.
+func formatDecls(generated []byte) ([]byte, error) {
+	var src = append([]byte(declsPackage), generated...)
+	content, err := format.Source(src)
+	if err != nil {
+		return nil, withSourceLine(fmt.Errorf("format: %w", err), src)
+	}
+
+	return content, nil
+}
+
+// importPaths returns the paths of the imports of the source,
+// sources, which can't be parsed, have no imports.
+func importPaths(src []byte) map[string]struct{} {
+	var paths = make(map[string]struct{})
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return paths
+	}
+
+	for _, imp := range f.Imports {
+		paths[specOf(imp).Path] = struct{}{}
+	}
+
+	return paths
+}
+
+// withSourceLine adds the line of the source, which caused the error, with the
+// preceding lines, so the problems of the generated code can be found without
+// the file itself.
+func withSourceLine(err error, src []byte) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	var (
+		lines = bytes.Split(src, []byte("\n"))
+		line  = list[0].Pos.Line
+		b     strings.Builder
+	)
+
+	if line < 1 || line > len(lines) {
+		return err
+	}
+
+	for i := max(1, line-2); i <= line; i++ {
+		b.WriteString(fmt.Sprintf("\n\t%d | %s", i, bytes.TrimRight(lines[i-1], " \t")))
+	}
+
+	return fmt.Errorf("%w%s", err, b.String())
+}
@@ -0,0 +1,247 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mergeImports(t *testing.T) {
+	type args struct {
+		existing   string
+		generated  string
+		candidates []string
+	}
+
+	type want struct {
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "no_import_declaration",
+			args: args{
+				existing:   "package p\n\nfunc Test_A(t *testing.T) {}\n",
+				generated:  "\nfunc Test_B(t *testing.T) { require.True(t, true) }\n",
+				candidates: []string{`"testing"`, `"github.com/stretchr/testify/require"`, `"fmt"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"testing\"\n\n\t\"github.com/stretchr/testify/require\"\n)\n\n" +
+					"func Test_A(t *testing.T) {}\n" +
+					"\nfunc Test_B(t *testing.T) { require.True(t, true) }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "single_import_without_parens",
+			args: args{
+				existing:   "package p\n\nimport \"testing\"\n\nfunc Test_A(t *testing.T) {}\n",
+				generated:  "\nfunc Test_B(t *testing.T) { _ = str.ToUpper(\"\") }\n",
+				candidates: []string{`"testing"`, `str "strings"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\tstr \"strings\"\n\t\"testing\"\n)\n\n" +
+					"func Test_A(t *testing.T) {}\n" +
+					"\nfunc Test_B(t *testing.T) { _ = str.ToUpper(\"\") }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "local_variables_are_not_packages",
+			args: args{
+				existing:   "package p\n\nimport (\n\t// comment\n\t\"testing\"\n)\n",
+				generated:  "\nfunc Test_B(t *testing.T) { var tt struct{ x int }; _ = tt.x }\n",
+				candidates: []string{`"testing"`, `tt "example.com/tt"`, `"example.com/pkg/v2"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t// comment\n\t\"testing\"\n)\n" +
+					"\nfunc Test_B(t *testing.T) { var tt struct{ x int }; _ = tt.x }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "major_version_suffix",
+			args: args{
+				existing:   "package p\n\nimport \"testing\"\n",
+				generated:  "\nfunc Test_B(t *testing.T) { pkg.Do() }\n",
+				candidates: []string{`"example.com/pkg/v2"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"testing\"\n\n\t\"example.com/pkg/v2\"\n)\n" +
+					"\nfunc Test_B(t *testing.T) { pkg.Do() }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "imports_added_to_groups",
+			args: args{
+				existing:   "package p\n\nimport (\n\t\"testing\"\n\n\t_ \"embed\"\n\n\t\"gopkg.in/yaml.v3\"\n)\n",
+				generated:  "\nfunc Test_B(t *testing.T) { _ = yaml.Node{}; _ = io.EOF; require.True(t, true) }\n",
+				candidates: []string{`"github.com/stretchr/testify/require"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"io\"\n\t\"testing\"\n\n\t_ \"embed\"\n\n" +
+					"\t\"github.com/stretchr/testify/require\"\n\t\"gopkg.in/yaml.v3\"\n)\n" +
+					"\nfunc Test_B(t *testing.T) { _ = yaml.Node{}; _ = io.EOF; require.True(t, true) }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "standard_group_created_before_others",
+			args: args{
+				existing:  "package p\n\nimport (\n\t\"gopkg.in/yaml.v3\"\n)\n",
+				generated: "\nfunc Test_B(t *testing.T) { _ = yaml.Node{} }\n",
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"testing\"\n\n\t\"gopkg.in/yaml.v3\"\n)\n" +
+					"\nfunc Test_B(t *testing.T) { _ = yaml.Node{} }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "existing_file_not_reformatted",
+			args: args{
+				existing:   "package p\n\nimport (\n\t\"testing\"\n\t\"io\"\n)\n\nfunc Test_A(t *testing.T)   {\n_ = io.EOF }\n",
+				generated:  "\nfunc Test_B(t *testing.T) {\n_ = fmt.Sprint() }\n",
+				candidates: []string{`"testing"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"fmt\"\n\t\"testing\"\n\t\"io\"\n)\n\nfunc Test_A(t *testing.T)   {\n_ = io.EOF }\n" +
+					"\nfunc Test_B(t *testing.T) {\n\t_ = fmt.Sprint()\n}\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "declared_names_are_not_packages",
+			args: args{
+				existing: "package p\n\nimport \"testing\"\n",
+				generated: "\nfunc Test_B(t *testing.T) {\n" +
+					"\tfor _, yaml := range []struct{ x int }{} { _ = yaml.x }\n" +
+					"\tif fmt := (struct{ x int }{}); true { _ = fmt.x }\n" +
+					"\tfunc(errors struct{ x int }) { _ = errors.x }(struct{ x int }{})\n" +
+					"\t_ = strings.ToUpper(\"\")\n}\n",
+				candidates: []string{`"gopkg.in/yaml.v3"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"strings\"\n\t\"testing\"\n)\n\nfunc Test_B(t *testing.T) {\n" +
+					"\tfor _, yaml := range []struct{ x int }{} {\n\t\t_ = yaml.x\n\t}\n" +
+					"\tif fmt := (struct{ x int }{}); true {\n\t\t_ = fmt.x\n\t}\n" +
+					"\tfunc(errors struct{ x int }) { _ = errors.x }(struct{ x int }{})\n" +
+					"\t_ = strings.ToUpper(\"\")\n}\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "invalid_existing_file",
+			args: args{
+				existing: "func Test_A(t *testing.T) {}",
+			},
+			want: want{
+				wantErr: require.Error,
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := mergeImports([]byte(tt.args.existing), []byte(tt.args.generated), tt.args.candidates)
+
+			tt.want.wantErr(t, gotErr)
+			if gotErr == nil {
+				require.Equal(t, tt.want.want, string(got))
+			}
+		})
+	}
+}
+
+func Test_fixImports(t *testing.T) {
+	type args struct {
+		src        string
+		protected  map[string]struct{}
+		candidates []string
+	}
+
+	type want struct {
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "unused_imports_removed",
+			args: args{
+				src: "package p\n\nimport (\n\t\"testing\"\n\t\"io\"\n\t\"gopkg.in/yaml.v3\"\n\t_ \"embed\"\n\t\"reflect\"\n)\n\n" +
+					"func Test_A(t *testing.T) { _ = reflect.DeepEqual(yaml.Node{}, nil) }\n",
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"reflect\"\n\t\"testing\"\n\n\t_ \"embed\"\n\n\t\"gopkg.in/yaml.v3\"\n)\n\n" +
+					"func Test_A(t *testing.T) { _ = reflect.DeepEqual(yaml.Node{}, nil) }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "unused_imports_removed_between_kept",
+			args: args{
+				src: "package p\n\nimport (\n\t\"testing\"\n\t\"errors\"\n\t\"io\"\n\t\"context\"\n\t\"strings\"\n\t\"reflect\"\n)\n\n" +
+					"func Test_A(t *testing.T) { _ = io.EOF; _ = strings.ToUpper; _ = reflect.DeepEqual }\n",
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"io\"\n\t\"reflect\"\n\t\"strings\"\n\t\"testing\"\n)\n\n" +
+					"func Test_A(t *testing.T) { _ = io.EOF; _ = strings.ToUpper; _ = reflect.DeepEqual }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "protected_imports_kept",
+			args: args{
+				src:       "package p\n\nimport \"io\"\n",
+				protected: map[string]struct{}{"io": {}},
+			},
+			want: want{
+				want:    "package p\n\nimport \"io\"\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "missing_imports_added",
+			args: args{
+				src:        "package p\n\nfunc Test_A(t *testing.T) { _ = context.TODO(); cmp.Diff(1, 2) }\n",
+				candidates: []string{`"github.com/google/go-cmp/cmp"`},
+			},
+			want: want{
+				want: "package p\n\nimport (\n\t\"context\"\n\t\"testing\"\n\n\t\"github.com/google/go-cmp/cmp\"\n)\n\n" +
+					"func Test_A(t *testing.T) { _ = context.TODO(); cmp.Diff(1, 2) }\n",
+				wantErr: require.NoError,
+			},
+		},
+		{
+			name: "offending_line_reported",
+			args: args{
+				src: "package p\n\nfunc Test_A(t *testing.T) {\n\tfor\n}\n",
+			},
+			want: want{
+				wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+					require.ErrorContains(t, err, "\n\t3 | func Test_A(t *testing.T) {\n\t4 | \tfor\n\t5 | }")
+				},
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := fixImports([]byte(tt.args.src), tt.args.protected, tt.args.candidates)
+
+			require.Equal(t, tt.want.want, string(got))
+			tt.want.wantErr(t, gotErr)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// markerPrefix starts the first line of the files created by the tool.
+	markerPrefix = "// Generated by ggt"
+
+	// markerOptionsPrefix starts the optional second line of the marker,
+	// which records the options used for the generation.
+	markerOptionsPrefix = "// Options:"
+)
+
+// marker returns the comment, which marks the files created by the tool,
+// recording the version of the tool and the options used.
+func (r *Renderer) marker() string {
+	var version = r.f.Version
+	if version == "" {
+		version = "devel"
+	}
+
+	var marker = fmt.Sprintf("%s %s, https://github.com/fadyat/ggt\n", markerPrefix, version)
+	if options := r.f.Options(); options != "" {
+		marker += fmt.Sprintf("%s %s\n", markerOptionsPrefix, options)
+	}
+
+	return marker
+}
+
+// updateMarker replaces the marker at the beginning of the content with the
+// actual one. Files without the marker are not created by the tool, so they
+// are returned as is.
+//
+// Options of the previous runs are kept, the options of the current run are
+// added as the new line, when they differ from all of them. Marker of the newer
+// release is not replaced, so the older binary doesn't downgrade the version.
+func updateMarker(content []byte, marker string) []byte {
+	if !bytes.HasPrefix(content, []byte(markerPrefix)) {
+		return content
+	}
+
+	var (
+		lines   = strings.Split(strings.TrimSuffix(marker, "\n"), "\n")
+		end     = markerLineEnd(content, 0)
+		options []string
+	)
+
+	if stored := strings.TrimSuffix(string(content[:end]), "\n"); isOlderVersion(markerVersion(lines[0]), markerVersion(stored)) {
+		lines[0] = stored
+	}
+
+	for bytes.HasPrefix(content[end:], []byte(markerOptionsPrefix)) {
+		var next = markerLineEnd(content, end)
+		options = append(options, strings.TrimSuffix(string(content[end:next]), "\n"))
+		end = next
+	}
+
+	for _, line := range lines[1:] {
+		if !slices.Contains(options, line) {
+			options = append(options, line)
+		}
+	}
+
+	var header = strings.Join(append(lines[:1], options...), "\n") + "\n"
+	var updated = make([]byte, 0, len(content)-end+len(header))
+	updated = append(updated, header...)
+	return append(updated, content[end:]...)
+}
+
+// markerVersion returns the version of the tool, recorded by the marker line.
+func markerVersion(line string) string {
+	version, _, _ := strings.Cut(strings.TrimPrefix(line, markerPrefix+" "), ",")
+	return version
+}
+
+// isOlderVersion reports whether both versions are the releases and the
+// version a is older than b. Other versions, like devel, are not compared.
+func isOlderVersion(a, b string) bool {
+	va, okA := releaseVersion(a)
+	vb, okB := releaseVersion(b)
+	return okA && okB && slices.Compare(va, vb) < 0
+}
+
+// releaseVersion parses the version in the vMAJOR.MINOR.PATCH format.
+func releaseVersion(s string) ([]int, bool) {
+	var parts = strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if !strings.HasPrefix(s, "v") || len(parts) != 3 {
+		return nil, false
+	}
+
+	var version = make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+
+		version = append(version, n)
+	}
+
+	return version, true
+}
+
+// markerLineEnd returns the position after the end of the line,
+// which starts at the position.
+func markerLineEnd(content []byte, start int) int {
+	var idx = bytes.IndexByte(content[start:], '\n')
+	if idx == -1 {
+		return len(content)
+	}
+
+	return start + idx + 1
+}
@@ -0,0 +1,99 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_updateMarker(t *testing.T) {
+	type args struct {
+		content string
+		marker  string
+	}
+
+	type want struct {
+		want string
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "older_marker_with_options",
+			args: args{
+				content: "// Generated by ggt v0.1.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n\npackage p\n",
+				marker:  "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n",
+			},
+			want: want{
+				want: "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n\npackage p\n",
+			},
+		},
+		{
+			name: "options_of_runs_kept",
+			args: args{
+				content: "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n" +
+					"// Options: -only=Foo\n\npackage p\n",
+				marker: "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n// Options: -assert=cmp\n",
+			},
+			want: want{
+				want: "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n" +
+					"// Options: -only=Foo\n// Options: -assert=cmp\n\npackage p\n",
+			},
+		},
+		{
+			name: "same_options_not_repeated",
+			args: args{
+				content: "// Generated by ggt v0.1.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n\npackage p\n",
+				marker:  "// Generated by ggt v0.1.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n",
+			},
+			want: want{
+				want: "// Generated by ggt v0.1.0, https://github.com/fadyat/ggt\n// Options: -assert=std\n\npackage p\n",
+			},
+		},
+		{
+			name: "newer_marker_kept",
+			args: args{
+				content: "// Generated by ggt v0.10.0, https://github.com/fadyat/ggt\n\npackage p\n",
+				marker:  "// Generated by ggt v0.9.1, https://github.com/fadyat/ggt\n",
+			},
+			want: want{want: "// Generated by ggt v0.10.0, https://github.com/fadyat/ggt\n\npackage p\n"},
+		},
+		{
+			name: "devel_marker_replaced",
+			args: args{
+				content: "// Generated by ggt v0.10.0, https://github.com/fadyat/ggt\n\npackage p\n",
+				marker:  "// Generated by ggt devel, https://github.com/fadyat/ggt\n",
+			},
+			want: want{want: "// Generated by ggt devel, https://github.com/fadyat/ggt\n\npackage p\n"},
+		},
+		{
+			name: "older_marker_without_options",
+			args: args{
+				content: "// Generated by ggt v0.1.0, https://github.com/fadyat/ggt\n// Custom header.\n\npackage p\n",
+				marker:  "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n// Options: -mocks=fake\n",
+			},
+			want: want{
+				want: "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n// Options: -mocks=fake\n" +
+					"// Custom header.\n\npackage p\n",
+			},
+		},
+		{
+			name: "file_without_marker",
+			args: args{
+				content: "// Package p is written by hand.\npackage p\n",
+				marker:  "// Generated by ggt v0.2.0, https://github.com/fadyat/ggt\n",
+			},
+			want: want{want: "// Package p is written by hand.\npackage p\n"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := updateMarker([]byte(tt.args.content), tt.args.marker)
+			require.Equal(t, tt.want.want, string(got))
+		})
+	}
+}
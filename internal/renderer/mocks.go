@@ -0,0 +1,117 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+
+	"github.com/fadyat/ggt/internal/lo"
+	"github.com/fadyat/ggt/internal/plugins"
+)
+
+// renderMocks renders the mocks into the companion file, appending only
+// the mocks, which are not declared there yet. Nil output is returned,
+// when there is nothing to add.
+func (r *Renderer) renderMocks(packageName string, mocks []*plugins.Mock) (*Output, error) {
+	if len(mocks) == 0 {
+		return nil, nil
+	}
+
+	var path = r.f.MocksFile()
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read mocks file: %w", err)
+	}
+
+	if mocks, err = undeclaredMocks(existing, mocks); err != nil {
+		return nil, fmt.Errorf("parse mocks file: %w", err)
+	}
+
+	if len(mocks) == 0 {
+		return nil, nil
+	}
+
+	var (
+		decls   = r.mocksSource(path, mocks)
+		imports = lo.FlatMap(mocks, func(m *plugins.Mock, _ int) []string { return m.Imports })
+		content []byte
+	)
+
+	if existing == nil {
+		content, err = fixImports(newMocksFile(r.marker(), packageName, decls), nil, imports)
+	} else {
+		content, err = mergeImports(existing, decls, imports)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fix imports: %w", err)
+	}
+
+	content = updateMarker(content, r.marker())
+
+	return &Output{Path: path, Existing: existing, Content: content}, nil
+}
+
+// undeclaredMocks returns the mocks, which are not declared in the existing
+// mocks file.
+func undeclaredMocks(existing []byte, mocks []*plugins.Mock) ([]*plugins.Mock, error) {
+	if existing == nil {
+		return mocks, nil
+	}
+
+	declared, err := declaredTypes(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.FilterMap(mocks, func(m *plugins.Mock, _ int) (*plugins.Mock, bool) {
+		_, ok := declared[m.Name]
+		return m, !ok
+	}), nil
+}
+
+// mocksSource returns the declarations of the mocks.
+func (r *Renderer) mocksSource(path string, mocks []*plugins.Mock) []byte {
+	var b bytes.Buffer
+	for _, m := range mocks {
+		r.logger.Debug("added mock", "output", path, "mock", m.Name)
+		b.WriteString("\n")
+		b.WriteString(m.Source)
+	}
+
+	return b.Bytes()
+}
+
+func newMocksFile(marker, packageName string, decls []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(marker)
+	b.WriteString(fmt.Sprintf("\npackage %s\n", packageName))
+	b.Write(decls)
+	return b.Bytes()
+}
+
+// declaredTypes returns the names of the types, declared in the file.
+func declaredTypes(src []byte) (map[string]struct{}, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var declared = make(map[string]struct{})
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			declared[spec.(*ast.TypeSpec).Name.Name] = struct{}{}
+		}
+	}
+
+	return declared, nil
+}
@@ -1,84 +1,136 @@
 package renderer
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/lo"
 	"github.com/fadyat/ggt/internal/plugins"
 )
 
+// tmpl is the built-in template of the test file.
+//
+// Parts of the layout are declared as named blocks, so they can be
+// redefined by the user templates without copying the whole file:
+//
+//   - header: package clause and imports, rendered only for the new files;
+//   - fields: types of the fields, arguments and results of the testcase;
+//   - testcases: declaration of the testcases;
+//   - run: loop, which runs the testcases;
+//   - bench: the whole benchmark of the function;
+//   - fuzz: the whole fuzz target of the function;
+//   - example: the whole example of the function.
 const tmpl = `
+{{- block "header" . }}
 {{- if .PackageName }}
 package {{ .PackageName }}
 {{- end }}
 
 {{- if .Imports }}
 import (
-    "testing"
-    "github.com/stretchr/testify/require"
     {{- range .Imports }}
     {{ . }}
     {{- end }}
 )
 {{- end }}
+{{- end }}
 
 {{ range .Functions }}
+{{- if .HasKind "test" }}
 func {{ .TestName }}(t *testing.T) {
-    {{- if .Struct }}
-    type fields {{ generics .Generics }} struct {
-        {{- range .Struct.Fields }}
+    {{- block "fields" . }}
+    {{- if .Fields }}
+    type fields {{ generics .Struct.Generics }} struct {
+        {{- range .Fields }}
         {{ .Name }} {{ arg_define .Type }}
         {{- end }}
     }
     {{- end }}
 
-    {{- if .Args }}
+    {{- if .TestArgs }}
     type args {{ generics .Generics }} struct {
-        {{- range .Args }}
+        {{- range .TestArgs }}
         {{ .Name }} {{ arg_define .Type }}
         {{- end }}
     }
     {{- end }}
 
-    {{- if .Results }}
+    {{- if or .Results .WantFields }}
     type want {{ generics .Generics }} struct {
         {{- range .Results }}
         {{ .Name }} {{ arg_define .Type }}
         {{- end }}
+        {{- range .WantFields }}
+        {{ .Name }} {{ .Type }}
+        {{- end }}
     }
     {{- end }}
 
+    {{- range .Declarations }}
+    {{ . }}
+    {{- end }}
+    {{- end }}
+
+    {{ block "testcases" . -}}
     testcases := []struct {
         name string
-        {{- if .Struct }}
-    	fields fields {{ generics_args .Generics }}
+        {{- if and .Struct (not .Struct.IsStruct) }}
+        receiver {{ .Struct.Name }}{{ generics_args .Struct.Generics }}
+        {{- end }}
+        {{- if .Fields }}
+    	fields fields {{ generics_args .Struct.Generics }}
     	{{- end }}
-    	{{- if .Args }}
+    	{{- if .TestArgs }}
     	args args {{ generics_args .Generics }}
     	{{- end }}
-    	{{- if .Results }}
+    	{{- if or .Results .WantFields }}
     	want want {{ generics_args .Generics }}
     	{{- end }}
+        {{- range .TestcaseFields }}
+        {{ .Name }} {{ .Type }}
+        {{- end }}
     }{
+        {{- range .Testcases }}
+        {{ . }},
+        {{- else }}
         {},
+        {{- end }}
     }
+    {{- end }}
 
+    {{ block "run" . -}}
     for _, tt := range testcases {
         t.Run(tt.name, func(t *testing.T) {
             {{- $got_results := .Results | collect "Name" | to_got }}
-            {{- $call_args := call_args .Args }}
+            {{- $call_args := .CallArgs | join ", " }}
 
-            {{- if .Struct }}
-            {{ .Receiver.Name }} := {{ .Struct.Name }}{
-                {{- range .Struct.Fields }}
-                {{ .Name }}: tt.fields.{{ .Name }},
+            {{- range .Setup }}
+            {{ . }}
+            {{- end }}
+
+            {{- with .Cleanup }}
+            {{ . }}
+            {{- end }}
+
+            {{- if and .Struct .Struct.IsStruct }}
+            {{ .Receiver.Name }} := {{ .Struct.Name }}{{ generics_args .Struct.Generics }}{
+                {{- range .StructFields }}
+                {{ .Name }}: {{ .Value }},
                 {{- end }}
             }
+            {{ else if .Struct }}
+            {{ .Receiver.Name }} := tt.receiver
             {{ end }}
 
             {{- if .Results }}
@@ -89,50 +141,384 @@ func {{ .TestName }}(t *testing.T) {
             {{ .Verification }}
         })
     }
+    {{- end }}
+}
+{{ end }}
+
+{{- if .HasKind "bench" }}
+{{ block "bench" . }}
+func {{ .BenchmarkName }}(b *testing.B) {
+    {{- if .BenchmarkFields }}
+    type fields {{ generics .Struct.Generics }} struct {
+        {{- range .BenchmarkFields }}
+        {{ .Name }} {{ arg_define .Type }}
+        {{- end }}
+    }
+    {{- end }}
+
+    {{- if .BenchmarkArgs }}
+    type args {{ generics .Generics }} struct {
+        {{- range .BenchmarkArgs }}
+        {{ .Name }} {{ arg_define .Type }}
+        {{- end }}
+    }
+    {{- end }}
+
+    benchmarks := []struct {
+        name string
+        {{- if and .Struct (not .Struct.IsStruct) }}
+        receiver {{ .Struct.Name }}{{ generics_args .Struct.Generics }}
+        {{- end }}
+        {{- if .BenchmarkFields }}
+        fields fields {{ generics_args .Struct.Generics }}
+        {{- end }}
+        {{- if .BenchmarkArgs }}
+        args args {{ generics_args .Generics }}
+        {{- end }}
+    }{
+        {},
+    }
+
+    for _, tt := range benchmarks {
+        b.Run(tt.name, func(b *testing.B) {
+            {{- if and .Struct .Struct.IsStruct }}
+            {{ .Receiver.Name }} := {{ .Struct.Name }}{{ generics_args .Struct.Generics }}{
+                {{- range .BenchmarkFields }}
+                {{ .Name }}: tt.fields.{{ .Name }},
+                {{- end }}
+            }
+            {{ else if .Struct }}
+            {{ .Receiver.Name }} := tt.receiver
+            {{ end }}
+
+            {{- if .BenchmarkLoop }}
+            for b.Loop() {
+            {{- else }}
+            for i := 0; i < b.N; i++ {
+            {{- end }}
+                {{ test_call . }}({{ .BenchmarkCallArgs | join ", " }})
+            }
+        })
+    }
+}
+{{ end }}
+{{- end }}
+
+{{- if .HasKind "fuzz" }}
+{{ block "fuzz" . }}
+func {{ .FuzzName }}(f *testing.F) {
+    f.Add({{ fuzz_seeds .Args }})
+    f.Fuzz(func(t *testing.T, {{ fuzz_params .Args }}) {
+        {{ test_call . }}({{ fuzz_args .Args }})
+    })
+}
+{{ end }}
+{{- end }}
+
+{{- if .HasKind "example" }}
+{{ block "example" . }}
+func {{ .ExampleName }}() {
+    {{- if or .Struct .Args }}
+    var (
+        {{- if .Struct }}
+        {{ .Receiver.Name }} {{ .Struct.Name }}
+        {{- end }}
+        {{- range .Args }}
+        {{ example_var . }} {{ arg_define .Type }}
+        {{- end }}
+    )
+    {{ end }}
+    {{ if .Results -}}
+    {{ $got_results := .Results | collect "Name" | to_got | join ", " -}}
+    {{ $got_results }} := {{ test_call . }}({{ example_args .Args }})
+    fmt.Println({{ $got_results }})
+    {{- else -}}
+    {{ test_call . }}({{ example_args .Args }})
+    {{- end }}
+    // Output:
 }
 {{ end }}
+{{- end }}
+{{ end }}
 `
 
 type Renderer struct {
-	f *internal.Flags
+	f      *internal.Flags
+	logger *slog.Logger
 }
 
-func NewRenderer(f *internal.Flags) *Renderer {
+func NewRenderer(f *internal.Flags, logger *slog.Logger) *Renderer {
 	return &Renderer{
-		f: f,
+		f:      f,
+		logger: logger,
+	}
+}
+
+// Output is the rendered file, which is not written to the disk yet.
+type Output struct {
+	Path string
+
+	// Existing is the current content of the file, nil for the new files.
+	Existing []byte
+
+	Content []byte
+}
+
+// Render renders the test file and the companion file with mocks, if
+// they are required, without writing them.
+func (r *Renderer) Render(file *plugins.PluggableFile) ([]*Output, error) {
+	existing, err := os.ReadFile(r.f.OutputFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read output file: %w", err)
+	}
+
+	var content []byte
+	if existing == nil {
+		content, err = r.renderNew(file)
+	} else {
+		content, err = r.renderAppend(existing, file)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs = []*Output{{Path: r.f.OutputFile, Existing: existing, Content: content}}
+	r.logger.Info("rendered tests", "output", r.f.OutputFile, "count", len(file.Functions))
+	r.logAddedImports(outputs[0])
+
+	mocks, err := r.renderMocks(file.PackageName, file.Mocks)
+	if err != nil {
+		return nil, fmt.Errorf("render mocks: %w", err)
+	}
+
+	if mocks != nil {
+		r.logAddedImports(mocks)
+		outputs = append(outputs, mocks)
+	}
+
+	return outputs, nil
+}
+
+// RenderMarker returns the output file with the updated marker, when the
+// file is generated by the other version or with the other options. It is
+// used, when there are no missing tests, so the tests are not rendered.
+// Nil is returned, when the marker is up to date.
+func (r *Renderer) RenderMarker() (*Output, error) {
+	existing, err := os.ReadFile(r.f.OutputFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read output file: %w", err)
+	}
+
+	var content = updateMarker(existing, r.marker())
+	if bytes.Equal(content, existing) {
+		return nil, nil
+	}
+
+	r.logger.Info("updated marker", "output", r.f.OutputFile)
+	return &Output{Path: r.f.OutputFile, Existing: existing, Content: content}, nil
+}
+
+// logAddedImports logs the imports, which are added to the output.
+func (r *Renderer) logAddedImports(out *Output) {
+	var before = make(map[string]struct{})
+	if out.Existing != nil {
+		before = importPaths(out.Existing)
+	}
+
+	var paths = lo.MapToSlice(importPaths(out.Content), func(path string, _ struct{}) string { return path })
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if _, ok := before[path]; !ok {
+			r.logger.Debug("added import", "output", out.Path, "import", path)
+		}
+	}
+}
+
+// Write writes the outputs to the disk.
+func Write(outputs []*Output) error {
+	for _, out := range outputs {
+		if err := os.WriteFile(out.Path, out.Content, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", out.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// renderNew renders the whole test file, including the package clause
+// and the imports, which are used by the tests.
+func (r *Renderer) renderNew(file *plugins.PluggableFile) ([]byte, error) {
+	var (
+		b       bytes.Buffer
+		newFile = *file
+	)
+
+	newFile.Imports = append(append([]string{}, defaultImports...), file.Imports...)
+	b.WriteString(r.marker())
+	b.WriteString(headerComment(r.f.Header))
+	if file.BuildConstraint != "" {
+		b.WriteString(file.BuildConstraint + "\n")
+	}
+
+	// separating the header from the package clause, so it is not treated
+	// as a package documentation, the build constraint requires it too.
+	b.WriteString("\n")
+	if err := r.renderTemplate(&b, &newFile); err != nil {
+		return nil, err
+	}
+
+	// all the imports of the tested file are passed to the template, so
+	// the unused ones are removed after the rendering.
+	content, err := fixImports(b.Bytes(), nil, newFile.Imports)
+	if err != nil {
+		return nil, fmt.Errorf("fix imports: %w", err)
 	}
+
+	return content, nil
 }
 
-func (r *Renderer) Render(file *plugins.PluggableFile) error {
-	flag, perms := os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(0666)
-	if _, err := os.Stat(r.f.OutputFile); err == nil {
-		flag, perms = os.O_RDWR|os.O_APPEND, os.FileMode(0644)
+// renderAppend renders only the missing tests and appends them to the
+// already existing test file, adding the imports required by them.
+func (r *Renderer) renderAppend(existing []byte, file *plugins.PluggableFile) ([]byte, error) {
+	var (
+		b       bytes.Buffer
+		fnsOnly = &plugins.PluggableFile{Functions: file.Functions}
+	)
+
+	if err := r.renderTemplate(&b, fnsOnly); err != nil {
+		return nil, err
 	}
 
-	f, err := os.OpenFile(r.f.OutputFile, flag, perms)
+	content, err := mergeImports(
+		existing,
+		b.Bytes(),
+		append(append([]string{}, defaultImports...), file.Imports...),
+	)
 	if err != nil {
-		return fmt.Errorf("open output file: %w", err)
+		return nil, fmt.Errorf("merge imports: %w", err)
 	}
-	defer f.Close()
 
-	return renderTemplate(f, file)
+	return updateMarker(content, r.marker()), nil
 }
 
-func renderTemplate(out io.Writer, data any) error {
+// headerComment converts the header text to the comment, which is placed
+// before the package clause, lines already being comments are kept as is.
+func headerComment(header string) string {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case strings.HasPrefix(line, "//"):
+			sb.WriteString(line)
+		case line == "":
+			sb.WriteString("//")
+		default:
+			sb.WriteString("// " + line)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func (r *Renderer) renderTemplate(out io.Writer, data any) error {
+	t, err := loadTemplate(r.f.Template)
+	if err != nil {
+		return err
+	}
+
+	if err = t.Execute(out, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+
+	return nil
+}
+
+// loadTemplate parses the built-in template and the user templates on top of
+// it, returning the template, which must be executed.
+//
+// User templates are named by their paths, so the parse and execution errors
+// point to the user files. Blocks defined by them replace the built-in ones,
+// while the content outside the blocks replaces the whole layout.
+func loadTemplate(userPath string) (*template.Template, error) {
 	t, err := template.
 		New("tmpl").
 		Funcs(funcMap()).
 		Parse(tmpl)
 
 	if err != nil {
-		return fmt.Errorf("parse template: %w", err)
+		return nil, fmt.Errorf("parse template: %w", err)
 	}
 
-	if err = t.ExecuteTemplate(out, "tmpl", data); err != nil {
-		return fmt.Errorf("execute template: %w", err)
+	if userPath == "" {
+		return t, nil
 	}
 
-	return nil
+	files, err := templateFiles(userPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var root = t
+	for _, file := range files {
+		content, readErr := os.ReadFile(file)
+		if readErr != nil {
+			return nil, fmt.Errorf("read template: %w", readErr)
+		}
+
+		ut, parseErr := t.New(file).Parse(string(content))
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse template: %w", parseErr)
+		}
+
+		if ut.Tree == nil || parse.IsEmptyTree(ut.Tree.Root) {
+			continue
+		}
+
+		if root != t {
+			return nil, fmt.Errorf("templates %s and %s both replace the whole layout", root.Name(), file)
+		}
+
+		root = ut
+	}
+
+	return root, nil
+}
+
+// templateFiles returns the user template itself or all the templates
+// with the .tmpl extension from the directory.
+func templateFiles(userPath string) ([]string, error) {
+	info, err := os.Stat(userPath)
+	if err != nil {
+		return nil, fmt.Errorf("read template: %w", err)
+	}
+
+	if !info.IsDir() {
+		return []string{userPath}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(userPath, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no templates found in %s", userPath)
+	}
+
+	return files, nil
 }
 
 func funcMap() template.FuncMap {
@@ -146,6 +532,11 @@ func funcMap() template.FuncMap {
 		"test_call":     testCall,
 		"arg_define":    argDefine,
 		"call_args":     callArgs,
+		"fuzz_seeds":    fuzzSeeds,
+		"fuzz_params":   fuzzParams,
+		"fuzz_args":     fuzzArgs,
+		"example_var":   exampleVar,
+		"example_args":  exampleArgs,
 	}
 }
 
@@ -261,3 +652,68 @@ func callArgs(args []*internal.Identifier) string {
 
 	return strings.Join(call, ", ")
 }
+
+// fuzzSeeds returns the zero values of the arguments, which are used as
+// the seed corpus entry of the fuzz target.
+func fuzzSeeds(args []*internal.Identifier) string {
+	var seeds = make([]string, 0, len(args))
+	for _, arg := range args {
+		switch arg.Type {
+		case "string":
+			seeds = append(seeds, `""`)
+		case "[]byte":
+			seeds = append(seeds, "[]byte{}")
+		case "bool":
+			seeds = append(seeds, "false")
+		default:
+			seeds = append(seeds, fmt.Sprintf("%s(0)", arg.Type))
+		}
+	}
+
+	return strings.Join(seeds, ", ")
+}
+
+// fuzzParam returns the name of the argument inside the fuzz function,
+// which doesn't conflict with the testing.T parameter.
+func fuzzParam(arg *internal.Identifier) string {
+	if arg.Name == "t" {
+		return "tArg"
+	}
+
+	return arg.Name
+}
+
+func fuzzParams(args []*internal.Identifier) string {
+	var params = make([]string, 0, len(args))
+	for _, arg := range args {
+		params = append(params, fmt.Sprintf("%s %s", fuzzParam(arg), arg.Type))
+	}
+
+	return strings.Join(params, ", ")
+}
+
+func fuzzArgs(args []*internal.Identifier) string {
+	return strings.Join(lo.Map(args, func(arg *internal.Identifier, _ int) string {
+		return fuzzParam(arg)
+	}), ", ")
+}
+
+// exampleVar returns the name of the variable with the placeholder value
+// of the argument, which doesn't shadow the fmt package.
+func exampleVar(arg *internal.Identifier) string {
+	if arg.Name == "fmt" {
+		return "fmtArg"
+	}
+
+	return arg.Name
+}
+
+func exampleArgs(args []*internal.Identifier) string {
+	return strings.Join(lo.Map(args, func(arg *internal.Identifier, _ int) string {
+		if strings.HasPrefix(arg.Type, "...") {
+			return exampleVar(arg) + "..."
+		}
+
+		return exampleVar(arg)
+	}), ", ")
+}
@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fadyat/ggt/internal"
+	"github.com/fadyat/ggt/internal/plugins"
+)
+
+func Test_loadTemplate(t *testing.T) {
+	type args struct {
+		files map[string]string
+	}
+
+	type want struct {
+		contains string
+		errMsg   string
+	}
+
+	var file = &plugins.PluggableFile{
+		PackageName: "p",
+		Functions: []*plugins.PluggableFn{
+			{Fn: &internal.Fn{Name: "Do"}},
+		},
+	}
+
+	testcases := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "block_override",
+			args: args{
+				files: map[string]string{
+					"run.tmpl": `{{ define "run" }}// run {{ .Name }}{{ end }}`,
+				},
+			},
+			want: want{contains: "testcases := []struct {\n        name string\n    }{\n        {},\n    }\n\n    // run Do\n}"},
+		},
+		{
+			name: "whole_layout",
+			args: args{
+				files: map[string]string{
+					"layout.tmpl": `package {{ .PackageName }}{{ range .Functions }} // {{ .TestName }}{{ end }}`,
+				},
+			},
+			want: want{contains: "package p // Test_Do"},
+		},
+		{
+			name: "parse_error_points_to_file",
+			args: args{
+				files: map[string]string{
+					"broken.tmpl": "\n{{ define \"run\" }}{{ if }}{{ end }}",
+				},
+			},
+			want: want{errMsg: "broken.tmpl:2: missing value for if"},
+		},
+		{
+			name: "execute_error_points_to_file",
+			args: args{
+				files: map[string]string{
+					"broken.tmpl": `{{ define "fields" }}{{ .Unknown }}{{ end }}`,
+				},
+			},
+			want: want{errMsg: `broken.tmpl:1:24: executing "fields" at <.Unknown>`},
+		},
+		{
+			name: "multiple_layouts",
+			args: args{
+				files: map[string]string{
+					"a.tmpl": "a",
+					"b.tmpl": "b",
+				},
+			},
+			want: want{errMsg: "both replace the whole layout"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			var dir = t.TempDir()
+			for name, content := range tt.args.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+			}
+
+			var (
+				b bytes.Buffer
+				r = NewRenderer(&internal.Flags{Template: dir}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			)
+
+			gotErr := r.renderTemplate(&b, file)
+			if tt.want.errMsg != "" {
+				require.ErrorContains(t, gotErr, tt.want.errMsg)
+				return
+			}
+
+			require.NoError(t, gotErr)
+			require.Contains(t, b.String(), tt.want.contains)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package basic
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Join joins the parts with the separator.
+func Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func Parse(s string) (int, error) {
+	if s == "" {
+		return 0, errors.New("empty")
+	}
+
+	return strconv.Atoi(s)
+}
+
+func Noop() {}
+
+func Map[T, R any](in []T, fn func(T) R) []R {
+	var out = make([]R, 0, len(in))
+	for _, v := range in {
+		out = append(out, fn(v))
+	}
+
+	return out
+}
+
+func (c *Counter) Add(n int) int {
+	c.total += n
+	return c.total
+}
+
+func (c Counter) Total() (total int, err error) {
+	return c.total, nil
+}
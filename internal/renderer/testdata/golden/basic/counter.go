@@ -0,0 +1,6 @@
+package basic
+
+type Counter struct {
+	name  string
+	total int
+}
@@ -0,0 +1,5 @@
+package buildtags
+
+func separator() string {
+	return `\`
+}
@@ -0,0 +1,11 @@
+//go:build integration
+
+package buildtags
+
+type Store struct {
+	dsn string
+}
+
+func (s *Store) DSN() string {
+	return s.dsn
+}
@@ -0,0 +1,11 @@
+//go:build !integration
+
+package buildtags
+
+type Store struct {
+	items map[string]string
+}
+
+func (s *Store) Len() int {
+	return len(s.items)
+}
@@ -0,0 +1,18 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+type Worker struct {
+	timeout time.Duration
+}
+
+func (w *Worker) Run(ctx context.Context, jobs []string) error {
+	return ctx.Err()
+}
+
+func Wait(ctx context.Context) {
+	<-ctx.Done()
+}
@@ -0,0 +1,40 @@
+package examples
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func Parse(fmt string) (int, error) {
+	return len(fmt), nil
+}
+
+func Reset() {}
+
+func helper() {}
+
+func Map[T any](v T) T {
+	return v
+}
+
+type Greeter struct {
+	name string
+}
+
+func (g *Greeter) Greet(greeting string) string {
+	return fmt.Sprintf("%s, %s", greeting, g.name)
+}
+
+type Level int
+
+func (l Level) String() string {
+	return fmt.Sprint(int(l))
+}
+
+type internalType struct{}
+
+func (internalType) Exported() {}
@@ -0,0 +1,29 @@
+package existing
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Sub(a, b int) int {
+	return a - b
+}
+
+func Mul(a, b int) int {
+	return a * b
+}
+
+type Calc struct {
+	value int
+}
+
+func (c *Calc) Reset() {
+	c.value = 0
+}
+
+func (c *Calc) Value() int {
+	return c.value
+}
+
+func (c *Calc) Store(v int) {
+	c.value = v
+}
@@ -0,0 +1,29 @@
+package existing
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("unexpected sum")
+	}
+}
+
+func TestCalc_Reset(t *testing.T) {
+	var c = Calc{value: 1}
+	c.Reset()
+}
+
+func TestCalc(t *testing.T) {
+	t.Run("Value", func(t *testing.T) {
+		var c Calc
+		if c.Value() != 0 {
+			t.Fatal("unexpected value")
+		}
+	})
+}
+
+func TestArithmetic(t *testing.T) {
+	if Mul(2, 3) != 6 {
+		t.Fatal("unexpected product")
+	}
+}
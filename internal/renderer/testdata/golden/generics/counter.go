@@ -0,0 +1,5 @@
+package generics
+
+type counter struct {
+	n int
+}
@@ -0,0 +1,7 @@
+//go:build ignore
+
+package generics
+
+type counter struct {
+	n int64
+}
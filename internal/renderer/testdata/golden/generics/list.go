@@ -0,0 +1,28 @@
+package generics
+
+type List[E any] struct {
+	items []E
+}
+
+func (l *List[T]) Push(v T) {
+	l.items = append(l.items, v)
+}
+
+func (l *List[T]) At(i int) T {
+	return l.items[i]
+}
+
+type Pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func (p Pair[K, V]) Key() K {
+	return p.key
+}
+
+type Counter = counter
+
+func (c *Counter) Inc() {
+	c.n++
+}
@@ -0,0 +1,19 @@
+package queue
+
+import "context"
+
+type Queue interface {
+	Push(ctx context.Context, items ...string) error
+	Len() int
+	Reset()
+}
+
+// Controller owns the queue, its receiver has the same name as the
+// variable of the mocks controller.
+type Controller struct {
+	queue Queue
+}
+
+func (ctrl *Controller) Enqueue(ctx context.Context, items []string) error {
+	return ctrl.queue.Push(ctx, items...)
+}
@@ -0,0 +1,42 @@
+package kinds
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+func Encode(data []byte, level int) (string, error) {
+	if level < 0 {
+		return "", errors.New("negative level")
+	}
+
+	return string(data), nil
+}
+
+type Codec struct {
+	io.Writer
+	level int
+}
+
+func (c *Codec) Level() int {
+	return c.level
+}
+
+type Flags uint8
+
+func (f Flags) Has(flag Flags) bool {
+	return f&flag != 0
+}
+
+func parse(s string, t bool) int {
+	if t {
+		return len(s)
+	}
+
+	return 0
+}
+
+func Fetch(ctx context.Context, key string) (string, error) {
+	return key, ctx.Err()
+}
@@ -0,0 +1,9 @@
+package kinds
+
+import "testing"
+
+func BenchmarkEncode(b *testing.B) {
+	for b.Loop() {
+		_, _ = Encode([]byte("data"), 1)
+	}
+}
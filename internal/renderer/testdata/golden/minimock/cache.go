@@ -0,0 +1,23 @@
+package cache
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Storage interface {
+	Load(keys ...string) (map[string][]byte, error)
+}
+
+// Cache keeps the values, its receiver has the same name as the
+// variable of the mocks controller.
+type Cache struct {
+	clock   Clock
+	storage Storage
+	ttl     time.Duration
+}
+
+func (mc *Cache) Expired(at time.Time) bool {
+	return mc.clock.Now().After(at.Add(mc.ttl))
+}
@@ -0,0 +1,19 @@
+package naming
+
+import "strconv"
+
+func parse(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+type Server struct {
+	port int
+}
+
+func (s *Server) Start() error {
+	return nil
+}
+
+func (s *Server) Port() int {
+	return s.port
+}
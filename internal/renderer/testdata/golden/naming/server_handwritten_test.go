@@ -0,0 +1,10 @@
+package naming
+
+import "testing"
+
+func TestServer_Start_Table(t *testing.T) {
+	var s Server
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
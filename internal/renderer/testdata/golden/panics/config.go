@@ -0,0 +1,25 @@
+package panics
+
+import "strconv"
+
+// MustPort parses the port number.
+func MustPort(s string) int {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return port
+}
+
+// Validate panics, when the name is empty.
+func Validate(name string) {
+	if name == "" {
+		panic("empty name")
+	}
+}
+
+// Port parses the port number. It never panics.
+func Port(s string) (int, error) {
+	return strconv.Atoi(s)
+}
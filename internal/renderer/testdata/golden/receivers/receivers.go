@@ -0,0 +1,35 @@
+package receivers
+
+import "strings"
+
+type names []string
+
+func (n names) Join(sep string) string {
+	return strings.Join(n, sep)
+}
+
+type b string
+
+func (b) String() string {
+	return "b"
+}
+
+type Handler func(v int) error
+
+func (h Handler) Call(v int) error {
+	return h(v)
+}
+
+type set map[string]struct{}
+
+func (s *set) Add(v string) {
+	(*s)[v] = struct{}{}
+}
+
+type Timer struct {
+	name string
+}
+
+func (t *Timer) Name() string {
+	return t.name
+}
@@ -0,0 +1,19 @@
+package seeds
+
+type Level uint8
+
+type ID = int64
+
+type Options struct {
+	Verbose bool
+}
+
+func Configure(level Level, id ID, ratio float32, r rune) bool {
+	return level > 0 && id > 0 && ratio > 0 && r > 0
+}
+
+func Merge(a, b map[string]int, opts *Options) map[string]int {
+	return nil
+}
+
+func Rename(from, to string, done chan struct{}) {}
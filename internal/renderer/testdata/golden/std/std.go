@@ -0,0 +1,21 @@
+package std
+
+import "errors"
+
+var ErrDivideByZero = errors.New("divide by zero")
+
+type Calculator struct {
+	precision int
+}
+
+func (c *Calculator) Divide(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, ErrDivideByZero
+	}
+
+	return a / b, nil
+}
+
+func Swap(a, b string) (string, string) {
+	return b, a
+}
@@ -0,0 +1,25 @@
+package testify
+
+import (
+	"context"
+	"io"
+)
+
+type Store interface {
+	Get(ctx context.Context, id string) (string, error)
+	Put(ctx context.Context, values ...string) error
+}
+
+type Service struct {
+	store Store
+	out   io.Writer
+	name  string
+}
+
+func (s *Service) Get(ctx context.Context, id string) (string, error) {
+	return s.store.Get(ctx, id)
+}
+
+func (s *Service) Close() error {
+	return nil
+}
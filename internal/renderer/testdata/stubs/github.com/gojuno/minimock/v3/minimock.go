@@ -0,0 +1,39 @@
+// Package minimock is a stub of the github.com/gojuno/minimock/v3 package, it
+// declares only the API used by the generated tests, so the golden outputs
+// are type-checked without the module.
+package minimock
+
+import "time"
+
+type Tester interface {
+	Fatal(args ...any)
+	Fatalf(format string, args ...any)
+	Error(args ...any)
+	Errorf(format string, args ...any)
+	FailNow()
+	Cleanup(f func())
+	Helper()
+}
+
+type Mocker interface {
+	MinimockFinish()
+	MinimockWait(time.Duration)
+}
+
+type MockController interface {
+	Tester
+	RegisterMocker(Mocker)
+}
+
+type Controller struct {
+	Tester
+	mockers []Mocker
+}
+
+func NewController(t Tester) *Controller {
+	return &Controller{Tester: t}
+}
+
+func (c *Controller) RegisterMocker(m Mocker) {
+	c.mockers = append(c.mockers, m)
+}
@@ -0,0 +1,54 @@
+// Package gomock is a stub of the go.uber.org/mock/gomock package, it declares
+// only the API used by the generated tests, so the golden outputs are
+// type-checked without the module.
+package gomock
+
+import "reflect"
+
+type TestReporter interface {
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+type TestHelper interface {
+	TestReporter
+	Helper()
+}
+
+type Controller struct {
+	T TestHelper
+}
+
+func NewController(t TestReporter) *Controller {
+	h, _ := t.(TestHelper)
+	return &Controller{T: h}
+}
+
+func (c *Controller) Call(receiver any, method string, args ...any) []any {
+	return nil
+}
+
+func (c *Controller) RecordCallWithMethodType(receiver any, method string, methodType reflect.Type, args ...any) *Call {
+	return &Call{}
+}
+
+type Call struct{}
+
+func (c *Call) Return(rets ...any) *Call { return c }
+
+func (c *Call) Times(n int) *Call { return c }
+
+func (c *Call) AnyTimes() *Call { return c }
+
+type Matcher interface {
+	Matches(x any) bool
+	String() string
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) Matches(any) bool { return true }
+
+func (anyMatcher) String() string { return "is anything" }
+
+func Any() Matcher { return anyMatcher{} }
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Summary collects the results of the generation for multiple input files.
+type Summary struct {
+	Generated []string
+	Skipped   []string
+	Failed    []*FailedFile
+}
+
+type FailedFile struct {
+	File string
+	Err  error
+}
+
+// Add stores the result of the generation for the input file, files
+// without missing tests or excluded by the build constraints are treated
+// as skipped.
+func (s *Summary) Add(file string, err error) {
+	switch {
+	case err == nil:
+		s.Generated = append(s.Generated, file)
+	case errors.Is(err, ErrNoMissingTests), errors.Is(err, ErrExcludedByBuild):
+		s.Skipped = append(s.Skipped, file)
+	default:
+		s.Failed = append(s.Failed, &FailedFile{File: file, Err: err})
+	}
+}
+
+func (s *Summary) String() string {
+	var sb strings.Builder
+	for _, file := range s.Generated {
+		sb.WriteString(fmt.Sprintf("generated: %s\n", file))
+	}
+
+	for _, file := range s.Skipped {
+		sb.WriteString(fmt.Sprintf("skipped: %s\n", file))
+	}
+
+	for _, failed := range s.Failed {
+		sb.WriteString(fmt.Sprintf("failed: %s: %s\n", failed.File, failed.Err))
+	}
+
+	sb.WriteString(fmt.Sprintf(
+		"%d generated, %d skipped, %d failed",
+		len(s.Generated), len(s.Skipped), len(s.Failed),
+	))
+
+	return sb.String()
+}
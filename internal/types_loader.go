@@ -0,0 +1,377 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+)
+
+// TypeLoader is responsible for the type-aware parsing of the package.
+//
+// Parsing based on the ast is not enough to understand, what the type
+// actually is: struct, interface, alias or a type from another package.
+// TypeLoader type-checks the whole package with go/types, importing the
+// dependencies from the source code, and fills the identifiers with the
+// resolved type information.
+//
+// Type-checking is tolerant to errors, unresolved types are just skipped.
+type TypeLoader struct {
+	dir string
+	ctx *build.Context
+
+	fs   *token.FileSet
+	pkg  *types.Package
+	errs []error
+
+	// uses and fieldTypes are used to detect the aliases from the source,
+	// because go/types doesn't keep them as the separate types, unless the
+	// gotypesalias setting is enabled, which is off for go1.22 modules.
+	uses       map[*ast.Ident]types.Object
+	fieldTypes map[token.Pos]ast.Expr
+}
+
+func NewTypeLoader(dir string, ctx *build.Context) *TypeLoader {
+	return &TypeLoader{
+		dir: dir,
+		ctx: ctx,
+		fs:  token.NewFileSet(),
+	}
+}
+
+// Load type-checks the package stored in the directory.
+func (l *TypeLoader) Load() error {
+	files, err := l.parsePackage()
+	if err != nil {
+		return err
+	}
+
+	var (
+		conf = l.config()
+		info = &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	)
+
+	l.pkg, _ = conf.Check(l.packagePath(files[0].Name.Name), l.fs, files, info)
+	l.uses = info.Uses
+	l.fieldTypes = fieldTypes(files)
+	return nil
+}
+
+// fieldTypes returns the type expressions of the parameters, results and
+// struct fields by the positions of their objects: the names or the types
+// themselves for the unnamed and embedded ones, as go/types sets them.
+func fieldTypes(files []*ast.File) map[token.Pos]ast.Expr {
+	var exprs = make(map[token.Pos]ast.Expr)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok {
+				return true
+			}
+
+			if len(field.Names) == 0 {
+				exprs[field.Type.Pos()] = field.Type
+			}
+
+			for _, name := range field.Names {
+				exprs[name.Pos()] = field.Type
+			}
+
+			return true
+		})
+	}
+
+	return exprs
+}
+
+// isAlias reports whether the variable is declared with the alias type,
+// like `id ID`, where `type ID = int`.
+func (l *TypeLoader) isAlias(v *types.Var) bool {
+	var expr = l.fieldTypes[v.Pos()]
+	for expr != nil {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			tn, ok := l.uses[e].(*types.TypeName)
+			return ok && tn.IsAlias()
+		case *ast.SelectorExpr:
+			expr = e.Sel
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// typeInfo returns the type information of the variable.
+func (l *TypeLoader) typeInfo(v *types.Var) *TypeInfo {
+	var info = newTypeInfo(v.Type())
+	if info != nil && l.isAlias(v) {
+		info.Alias = true
+	}
+
+	return info
+}
+
+// LoadCalls type-checks the package together with the test files and returns
+// the full names of the functions and methods of the package, which are called
+// by the tests, like `Name` or `Type.Name`.
+//
+// Tests of the external test package import the package checked together with
+// the internal tests, so the called objects are the same.
+func (l *TypeLoader) LoadCalls(testFiles []string) (map[string]struct{}, error) {
+	files, err := l.parsePackage()
+	if err != nil {
+		return nil, err
+	}
+
+	var internalTests, externalTests []*ast.File
+	for _, path := range testFiles {
+		f, parseErr := parser.ParseFile(l.fs, path, nil, parser.AllErrors)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse test file: %w", parseErr)
+		}
+
+		if f.Name.Name == files[0].Name.Name {
+			internalTests = append(internalTests, f)
+		} else {
+			externalTests = append(externalTests, f)
+		}
+	}
+
+	var (
+		conf = l.config()
+		info = &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	)
+
+	l.pkg, _ = conf.Check(l.packagePath(files[0].Name.Name), l.fs, append(files, internalTests...), info)
+	if len(externalTests) > 0 {
+		conf.Importer = testedPackageImporter{Importer: conf.Importer, pkg: l.pkg}
+		_, _ = conf.Check(l.pkg.Path()+"_test", l.fs, externalTests, info)
+	}
+
+	var calls = make(map[string]struct{})
+	for _, f := range append(internalTests, externalTests...) {
+		collectCalls(f, info, l.pkg, calls)
+	}
+
+	return calls, nil
+}
+
+func (l *TypeLoader) parsePackage() ([]*ast.File, error) {
+	packageFiles, err := listPackageFiles(l.dir, defaultExcludeFunc(""))
+	if err != nil {
+		return nil, fmt.Errorf("list package files: %w", err)
+	}
+
+	packageFiles, _, err = matchBuildContext(l.ctx, l.dir, packageFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var files = make([]*ast.File, 0, len(packageFiles))
+	for _, file := range packageFiles {
+		f, err := parser.ParseFile(l.fs, filepath.Join(l.dir, file), nil, parser.AllErrors)
+		if err != nil {
+			return nil, fmt.Errorf("parse file: %w", err)
+		}
+
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no go files in %s", l.dir)
+	}
+
+	return files, nil
+}
+
+func (l *TypeLoader) config() *types.Config {
+	return &types.Config{
+		Importer: importer.ForCompiler(l.fs, "source", nil),
+		Error: func(err error) {
+			l.errs = append(l.errs, err)
+		},
+	}
+}
+
+// testedPackageImporter returns the already checked package for its path.
+type testedPackageImporter struct {
+	types.Importer
+	pkg *types.Package
+}
+
+func (i testedPackageImporter) Import(path string) (*types.Package, error) {
+	if path == i.pkg.Path() {
+		return i.pkg, nil
+	}
+
+	return i.Importer.Import(path)
+}
+
+// packagePath returns the import path of the loaded package, using the
+// package name as a fallback, when the directory is not a part of module.
+func (l *TypeLoader) packagePath(name string) string {
+	m, err := findModule(l.dir)
+	if err != nil {
+		return name
+	}
+
+	path, err := m.importPath(l.dir)
+	if err != nil {
+		return name
+	}
+
+	return path
+}
+
+// Errors returns the errors, which occurred during the type-checking.
+func (l *TypeLoader) Errors() error {
+	return errors.Join(l.errs...)
+}
+
+// Fill sets the type information for the function identifiers
+// and the fields of the receiver struct.
+func (l *TypeLoader) Fill(file *File) {
+	file.PackagePath = l.pkg.Path()
+
+	for _, fn := range file.Functions {
+		sig, ok := l.signature(fn)
+		if !ok {
+			continue
+		}
+
+		if fn.Receiver != nil {
+			fn.Receiver.Info = l.typeInfo(sig.Recv())
+		}
+
+		l.fillTuple(fn.Args, sig.Params())
+		l.fillTuple(fn.Results, sig.Results())
+
+		if fn.Struct != nil && fn.Receiver != nil {
+			l.fillStruct(fn.Struct, fn.Receiver.Info)
+		}
+	}
+}
+
+func (l *TypeLoader) signature(fn *Fn) (*types.Signature, bool) {
+	var obj types.Object
+	if fn.Receiver == nil {
+		obj = l.pkg.Scope().Lookup(fn.Name)
+	} else {
+		recv := l.pkg.Scope().Lookup(fn.structTypeBasedOnReceiver())
+		if recv == nil {
+			return nil, false
+		}
+
+		obj, _, _ = types.LookupFieldOrMethod(recv.Type(), true, l.pkg, fn.Name)
+	}
+
+	f, ok := obj.(*types.Func)
+	if !ok {
+		return nil, false
+	}
+
+	sig, ok := f.Type().(*types.Signature)
+	return sig, ok
+}
+
+func (l *TypeLoader) fillTuple(identifiers []*Identifier, tuple *types.Tuple) {
+	if tuple == nil || tuple.Len() != len(identifiers) {
+		return
+	}
+
+	for i, identifier := range identifiers {
+		identifier.Info = l.typeInfo(tuple.At(i))
+	}
+}
+
+func (l *TypeLoader) fillStruct(s *Struct, recv *TypeInfo) {
+	if recv == nil {
+		return
+	}
+
+	var typ = recv.Type
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok || st.NumFields() != len(s.Fields) {
+		return
+	}
+
+	for i, field := range s.Fields {
+		field.Info = l.typeInfo(st.Field(i))
+	}
+}
+
+func newTypeInfo(t types.Type) *TypeInfo {
+	if t == nil || t == types.Typ[types.Invalid] {
+		return nil
+	}
+
+	var info = &TypeInfo{
+		Type:       t,
+		Kind:       typeKind(t),
+		Underlying: t.Underlying().String(),
+	}
+
+	if _, ok := t.(*types.Alias); ok {
+		info.Alias = true
+	}
+
+	t = types.Unalias(t)
+	for {
+		ptr, ok := t.(*types.Pointer)
+		if !ok {
+			break
+		}
+
+		t = types.Unalias(ptr.Elem())
+	}
+
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		info.PkgPath = named.Obj().Pkg().Path()
+	}
+
+	return info
+}
+
+func typeKind(t types.Type) TypeKind {
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		return KindTypeParam
+	}
+
+	switch t.Underlying().(type) {
+	case *types.Struct:
+		return KindStruct
+	case *types.Interface:
+		return KindInterface
+	case *types.Pointer:
+		return KindPointer
+	case *types.Slice:
+		return KindSlice
+	case *types.Array:
+		return KindArray
+	case *types.Map:
+		return KindMap
+	case *types.Chan:
+		return KindChan
+	case *types.Signature:
+		return KindFunc
+	default:
+		return KindBasic
+	}
+}
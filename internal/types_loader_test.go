@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"go/build"
+	"go/types"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const typesLoaderSource = `package p
+
+import "context"
+
+type Storage interface {
+	Get(ctx context.Context, id string) (string, error)
+}
+
+type ID = int
+
+type Service struct {
+	storage Storage
+	ids     []ID
+}
+
+func (s *Service) Find(ctx context.Context, id ID) (*Service, error) {
+	return s, nil
+}
+`
+
+func Test_TypeLoader_Fill(t *testing.T) {
+	var dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "p.go"), []byte(typesLoaderSource), 0o600))
+
+	parser := NewParser(&Flags{
+		InputFile:  filepath.Join(dir, "p.go"),
+		OutputFile: filepath.Join(dir, "p_test.go"),
+		TypeCheck:  true,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	file, err := parser.GenerateMissingTests()
+	require.NoError(t, err)
+	require.Len(t, file.Functions, 1)
+	require.Equal(t, "p", file.PackagePath)
+
+	fn := file.Functions[0]
+	require.Equal(t, KindPointer, fn.Receiver.Info.Kind)
+	require.Equal(t, "p", fn.Receiver.Info.PkgPath)
+
+	require.Equal(t, KindInterface, fn.Args[0].Info.Kind)
+	require.Equal(t, "context", fn.Args[0].Info.PkgPath)
+	require.True(t, fn.Args[0].Info.IsInterface())
+	require.Equal(t, KindBasic, fn.Args[1].Info.Kind)
+	require.True(t, fn.Args[1].Info.Alias)
+
+	require.Equal(t, KindPointer, fn.Results[0].Info.Kind)
+	require.True(t, fn.Results[1].Info.IsInterface())
+
+	require.True(t, fn.Struct.Fields[0].Info.IsInterface())
+	require.Equal(t, KindSlice, fn.Struct.Fields[1].Info.Kind)
+	require.Equal(t, "[]p.ID", fn.Struct.Fields[1].Info.Underlying)
+}
+
+const callsSource = `package p
+
+type Client struct{}
+
+func New() *Client { return &Client{} }
+
+func (c *Client) Reset() {}
+
+func (c *Client) Close() {}
+
+func Parse() {}
+`
+
+const callsInternalTest = `package p
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	var c Client
+	c.Close()
+
+	var b strings.Builder
+	b.Reset()
+
+	_ = errors.New("new")
+}
+`
+
+const callsExternalTest = `package p_test
+
+import (
+	"testing"
+
+	"p"
+)
+
+func TestParse(t *testing.T) {
+	p.Parse()
+}
+`
+
+func Test_TypeLoader_LoadCalls(t *testing.T) {
+	var dir = t.TempDir()
+	for name, content := range map[string]string{
+		"p.go":          callsSource,
+		"p_test.go":     callsInternalTest,
+		"p_ext_test.go": callsExternalTest,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	calls, err := NewTypeLoader(dir, &build.Default).LoadCalls([]string{
+		filepath.Join(dir, "p_test.go"),
+		filepath.Join(dir, "p_ext_test.go"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]struct{}{"Client.Close": {}, "Parse": {}}, calls)
+}
+
+const aliasesSource = `package p
+
+import "os"
+
+type ID = int
+
+type Pair[T any] struct{ a, b T }
+
+type Pairs = Pair[int]
+
+type Named int
+
+func F(id ID, ids []ID, ptr *ID, pairs Pairs, err os.PathError, named Named, _ int) {}
+`
+
+func Test_TypeLoader_isAlias(t *testing.T) {
+	var dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "p.go"), []byte(aliasesSource), 0o600))
+
+	var loader = NewTypeLoader(dir, &build.Default)
+	require.NoError(t, loader.Load())
+
+	var params = loader.pkg.Scope().Lookup("F").Type().(*types.Signature).Params()
+
+	testcases := []struct {
+		name string
+		want bool
+	}{
+		{name: "id", want: true},
+		{name: "ids", want: false},
+		{name: "ptr", want: false},
+		{name: "pairs", want: true},
+		{name: "err", want: true},
+		{name: "named", want: false},
+		{name: "_", want: false},
+	}
+
+	for i, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, loader.isAlias(params.At(i)))
+		})
+	}
+}